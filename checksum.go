@@ -0,0 +1,110 @@
+package serializer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// ErrChecksumMismatch is returned when the CRC32C trailer of an array not match its body
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// EncodeWithChecksum encode v like Encode, and add a CRC32C trailer as the last item of the array.
+// the CRC32C is computed over the V1 body of the array, v must be encoded as an array, like []interface{} or a struct.
+func EncodeWithChecksum(buf []byte, tag int, v interface{}) ([]byte, error) {
+	start := len(buf)
+	buf, err := Encode(buf, tag, v)
+	if err != nil {
+		return buf, err
+	}
+	golangType, _, typ, n := consumeHead(buf[start:])
+	if n < 0 || golangType != 0 || typ != protowire.StartGroupType {
+		return buf[:start], fmt.Errorf("[%s]not a array, %T", debugs.SourceCodeLoc(1), v)
+	}
+	buf = buf[:len(buf)-protowire.SizeTag(protowire.Number(tag))]
+	return AppendArrayEndWithChecksum(buf, tag, start), nil
+}
+
+// AppendArrayEndWithChecksum add the CRC32C trailer and the array end flag to buffer,
+// start is the offset of the array header in buf, which is added by AppendArrayStart
+func AppendArrayEndWithChecksum(buf []byte, tag int, start int) []byte {
+	body := buf[start+protowire.SizeTag(protowire.Number(tag)):]
+	sum := crc32.Checksum(body, crc32cTable)
+	buf = setType(buf, tChecksum)
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.Fixed32Type)
+	buf = protowire.AppendFixed32(buf, sum)
+	return AppendArrayEnd(buf, tag)
+}
+
+// consumeChecksum read the CRC32C trailer at item, and check it with body(from the array header to the trailer),
+// return the length of the trailer
+func consumeChecksum(body []byte, item []byte) (int, error) {
+	sum, n, err := readChecksum(item, FormatV1)
+	if err != nil {
+		return 0, err
+	}
+	if crc32.Checksum(body, crc32cTable) != sum {
+		return 0, debugs.WarpError(ErrChecksumMismatch, fmt.Sprintf("expect %08x", sum))
+	}
+	return n, nil
+}
+
+// readChecksum read the CRC32C trailer at item in the format version, n is the length of the trailer
+func readChecksum(item []byte, version int) (sum uint32, n int, err error) {
+	_, _, typ, headLen := readHead(item, version)
+	if headLen < 0 {
+		return 0, 0, fmt.Errorf("[%s]read checksum error,code=%d", debugs.SourceCodeLoc(1), headLen)
+	}
+	if typ != protowire.Fixed32Type {
+		return 0, 0, fmt.Errorf("[%s]checksum not a Fixed32Type, type=%d", debugs.SourceCodeLoc(1), typ)
+	}
+	sum, dataLen := protowire.ConsumeFixed32(item[headLen:])
+	if dataLen < 0 {
+		return 0, 0, fmt.Errorf("[%s]read checksum error,code=%d", debugs.SourceCodeLoc(1), dataLen)
+	}
+	return sum, headLen + dataLen, nil
+}
+
+// verifyArray check the CRC32C trailer of an array if present, body is the data between array header and end flag.
+// the trailer is the last item of the array(or before the index trailer), so an array without it is not walked
+func verifyArray(body []byte, tag protowire.Number) error {
+	if !hasChecksumTrailer(body, tag) {
+		return nil
+	}
+	for item := body; len(item) > 0; {
+		golangType, _, _, n := consumeHead(item)
+		if n < 0 {
+			return fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		if golangType == tChecksum {
+			_, err := consumeChecksum(body[:len(body)-len(item)], item)
+			return err
+		}
+		leftData, err := skipValue(item)
+		if err != nil {
+			return debugs.WarpError(err, "skipValue error")
+		}
+		item = leftData
+	}
+	return nil
+}
+
+// hasChecksumTrailer check if body ends with the bytes of a CRC32C trailer in V1, the index trailer after it is skipped.
+// the bytes may be the end of another value, the caller must walk the items to make sure
+func hasChecksumTrailer(body []byte, tag protowire.Number) bool {
+	if trailer := indexTrailer(body, tag, FormatV1); trailer != nil {
+		body = body[:len(body)-len(trailer)]
+	}
+	var temp [16]byte
+	head := setType(temp[:0], tChecksum)
+	head = protowire.AppendTag(head, tag, protowire.Fixed32Type)
+	n := len(head) + 4
+	return len(body) >= n && bytes.Equal(body[len(body)-n:len(body)-4], head)
+}
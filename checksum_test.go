@@ -0,0 +1,151 @@
+package serializer
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeWithChecksum(t *testing.T) {
+	arr := getTestData()
+	buf, err := EncodeWithChecksum(nil, 1, arr)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	_, values, err := Decode(buf)
+	if err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual(arr, values) {
+		t.Errorf("not equal, %+v", values)
+		return
+	}
+	// change a value of the first row
+	buf[5] ^= 0x01
+	if _, _, err = Decode(buf); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("should be ErrChecksumMismatch, err=%+v", err)
+	}
+	if _, _, _, _, err = ReadArray(buf); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("should be ErrChecksumMismatch, err=%+v", err)
+	}
+	if _, err = EncodeWithChecksum(nil, 1, 123); err == nil {
+		t.Errorf("not a array should fail")
+	}
+}
+
+func TestAppendArrayEndWithChecksum(t *testing.T) {
+	rows := getTestRows()
+	buf := []byte("prefix")
+	start := len(buf)
+	buf = AppendArrayStart(buf, 1)
+	var err error
+	for idx, row := range rows {
+		if buf, err = EncodeWithChecksum(buf, idx+1, row); err != nil {
+			t.Errorf("encode error, err=%+v", err)
+			return
+		}
+	}
+	buf = AppendArrayEndWithChecksum(buf, 1, start)
+	var got [][]interface{}
+	err = ReadEachRow(buf[start:], func(tag int, cols ...interface{}) error {
+		got = append(got, cols)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("read error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual(rows, got) {
+		t.Errorf("not equal, %+v", got)
+		return
+	}
+	// checksum is kept in V2
+	v2, _, err := convertValue(AppendHeader(nil, FormatV2), buf[start:], FormatV1, FormatV2)
+	if err != nil {
+		t.Errorf("convert error, err=%+v", err)
+		return
+	}
+	if _, _, err = Decode(v2); err != nil {
+		t.Errorf("decode V2 error, err=%+v", err)
+		return
+	}
+	buf[len(buf)-10] ^= 0x01
+	err = ReadEachRow(buf[start:], func(tag int, cols ...interface{}) error { return nil })
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("should be ErrChecksumMismatch, err=%+v", err)
+	}
+}
+
+func TestDecodeIntoWithChecksum(t *testing.T) {
+	type row struct {
+		A int
+		B string
+	}
+	buf, err := EncodeWithChecksum(nil, 1, row{A: 1, B: "b"})
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	var out row
+	if _, err = DecodeInto(buf, &out); err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	if out.A != 1 || out.B != "b" {
+		t.Errorf("not equal, %+v", out)
+	}
+}
+
+func TestReadArrayLooksLikeChecksum(t *testing.T) {
+	// the last bytes value ends with the same bytes as a CRC32C trailer of tag 1
+	arr := []interface{}{"a", []byte{0x78, byte(tChecksum), 0x0d, 1, 2, 3, 4}}
+	buf, err := Encode(nil, 1, arr)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	if _, _, _, _, err = ReadArray(buf); err != nil {
+		t.Errorf("read error, err=%+v", err)
+	}
+	if _, values, err := Decode(buf); err != nil || !reflect.DeepEqual(arr, values) {
+		t.Errorf("not equal, values=%+v, err=%+v", values, err)
+	}
+	// looking for the trailer does not allocate
+	allocs := testing.AllocsPerRun(10, func() {
+		_, _, _, _, _ = ReadArray(buf)
+	})
+	if allocs != 0 {
+		t.Errorf("ReadArray allocated %v times", allocs)
+	}
+}
+
+// the checksum is checked when the index trailer is added after it
+func TestChecksumWithIndexTrailer(t *testing.T) {
+	rows := []interface{}{[]interface{}{1, "hello"}, []interface{}{2, "world"}}
+	buf, err := EncodeWithChecksum(nil, 1, rows)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	if buf, err = AppendIndexTrailer(buf); err != nil {
+		t.Errorf("AppendIndexTrailer error, err=%+v", err)
+		return
+	}
+	if _, _, _, _, err = ReadArray(buf); err != nil {
+		t.Errorf("read error, err=%+v", err)
+	}
+	buf[bytes.Index(buf, []byte("hello"))] = 'j'
+	if _, _, _, _, err = ReadArray(buf); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("ReadArray should be ErrChecksumMismatch, err=%+v", err)
+	}
+	err = ReadEachRow(buf, func(tag int, cols ...interface{}) error { return nil })
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("ReadEachRow should be ErrChecksumMismatch, err=%+v", err)
+	}
+	if _, _, err = Decode(buf); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Decode should be ErrChecksumMismatch, err=%+v", err)
+	}
+}
@@ -0,0 +1,488 @@
+// Code generated by serializergen. DO NOT EDIT.
+
+package example
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// AppendSerializer append fields of Point to buf, the format is same as serializer.Encode for a []interface{} row.
+// use serializer.AppendArrayStart/AppendArrayEnd around it to make a row.
+func (v *Point) AppendSerializer(buf []byte) []byte {
+	buf = append(buf, 0x78, 19) // data type: int32
+	buf = protowire.AppendTag(buf, 1, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(v.X)))
+	buf = append(buf, 0x78, 19) // data type: int32
+	buf = protowire.AppendTag(buf, 2, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(v.Y)))
+	return buf
+}
+
+// ConsumeSerializer read fields of Point from buf until the group end tag or the end of buf,
+// it returns the data from the group end tag. bytes fields refer to buf.
+func (v *Point) ConsumeSerializer(buf []byte) ([]byte, error) {
+	for len(buf) > 0 {
+		num, typ, n := protowire.ConsumeTag(buf)
+		if n < 0 {
+			return buf, protowire.ParseError(n)
+		}
+		if typ == protowire.EndGroupType {
+			return buf, nil
+		}
+		dataType := uint64(0)
+		if num == 15 && typ == protowire.VarintType {
+			var m int
+			dataType, m = protowire.ConsumeVarint(buf[n:])
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[n+m:]
+			if num, typ, n = protowire.ConsumeTag(buf); n < 0 {
+				return buf, protowire.ParseError(n)
+			}
+		}
+		buf = buf[n:]
+		switch num {
+		case 1:
+			if typ != protowire.VarintType {
+				return buf, fmt.Errorf("field X: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeVarint(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 19:
+				v.X = int32(protowire.DecodeZigZag(x))
+			case 6:
+				v.X = int32(x)
+			default:
+				return buf, fmt.Errorf("field X: unexpected data type %d", dataType)
+			}
+		case 2:
+			if typ != protowire.VarintType {
+				return buf, fmt.Errorf("field Y: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeVarint(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 19:
+				v.Y = int32(protowire.DecodeZigZag(x))
+			case 6:
+				v.Y = int32(x)
+			default:
+				return buf, fmt.Errorf("field Y: unexpected data type %d", dataType)
+			}
+		default:
+			m := protowire.ConsumeFieldValue(num, typ, buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+		}
+	}
+	return buf, nil
+}
+
+// AppendSerializer append fields of Row to buf, the format is same as serializer.Encode for a []interface{} row.
+// use serializer.AppendArrayStart/AppendArrayEnd around it to make a row.
+func (v *Row) AppendSerializer(buf []byte) []byte {
+	buf = append(buf, 0x78, 20) // data type: int64
+	buf = protowire.AppendTag(buf, 1, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(v.ID))
+	buf = append(buf, 0x78, 13) // data type: string
+	buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+	buf = protowire.AppendString(buf, v.Name)
+	buf = append(buf, 0x78, 1) // data type: bool
+	buf = protowire.AppendTag(buf, 3, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, protowire.EncodeBool(v.OK))
+	buf = append(buf, 0x78, 17) // data type: int8
+	buf = protowire.AppendTag(buf, 4, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(v.Level)))
+	buf = append(buf, 0x78, 3) // data type: uint8
+	buf = protowire.AppendTag(buf, 5, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, uint64(v.Code))
+	buf = append(buf, 0x78, 5) // data type: uint16
+	buf = protowire.AppendTag(buf, 6, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, uint64(v.Port))
+	buf = append(buf, 0x78, 19) // data type: int32
+	buf = protowire.AppendTag(buf, 7, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(v.Delta)))
+	buf = append(buf, 0x78, 7) // data type: uint32
+	buf = protowire.AppendTag(buf, 8, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, uint64(v.Count))
+	buf = append(buf, 0x78, 9) // data type: uint64
+	buf = protowire.AppendTag(buf, 9, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, v.Total)
+	buf = append(buf, 0x78, 21) // data type: int
+	buf = protowire.AppendTag(buf, 10, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(v.Offset)))
+	buf = append(buf, 0x78, 11) // data type: float32
+	buf = protowire.AppendTag(buf, 11, protowire.Fixed32Type)
+	buf = protowire.AppendFixed32(buf, math.Float32bits(v.Ratio))
+	buf = append(buf, 0x78, 12) // data type: float64
+	buf = protowire.AppendTag(buf, 12, protowire.Fixed64Type)
+	buf = protowire.AppendFixed64(buf, math.Float64bits(v.Value))
+	if v.Raw != nil {
+		buf = append(buf, 0x78, 14) // data type: []byte
+		buf = protowire.AppendTag(buf, 13, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, v.Raw)
+	}
+	if v.Samples != nil {
+		buf = append(buf, 0x78, 31) // data type: []float64
+		buf = protowire.AppendTag(buf, 14, protowire.BytesType)
+		buf = protowire.AppendVarint(buf, uint64(len(v.Samples)*8))
+		for _, item := range v.Samples {
+			buf = protowire.AppendFixed64(buf, math.Float64bits(item))
+		}
+	}
+	if v.Deltas != nil {
+		buf = append(buf, 0x78, 27) // data type: []int64
+		buf = protowire.AppendTag(buf, 15, protowire.BytesType)
+		size := 0
+		for _, item := range v.Deltas {
+			size += protowire.SizeVarint(protowire.EncodeZigZag(item))
+		}
+		buf = protowire.AppendVarint(buf, uint64(size))
+		for _, item := range v.Deltas {
+			buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(item))
+		}
+	}
+	buf = protowire.AppendTag(buf, 16, protowire.StartGroupType)
+	buf = v.Pos.AppendSerializer(buf)
+	buf = protowire.AppendTag(buf, 16, protowire.EndGroupType)
+	buf = append(buf, 0x78, 18) // data type: int16
+	buf = protowire.AppendTag(buf, 17, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(v.Short)))
+	return buf
+}
+
+// ConsumeSerializer read fields of Row from buf until the group end tag or the end of buf,
+// it returns the data from the group end tag. bytes fields refer to buf.
+func (v *Row) ConsumeSerializer(buf []byte) ([]byte, error) {
+	for len(buf) > 0 {
+		num, typ, n := protowire.ConsumeTag(buf)
+		if n < 0 {
+			return buf, protowire.ParseError(n)
+		}
+		if typ == protowire.EndGroupType {
+			return buf, nil
+		}
+		dataType := uint64(0)
+		if num == 15 && typ == protowire.VarintType {
+			var m int
+			dataType, m = protowire.ConsumeVarint(buf[n:])
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[n+m:]
+			if num, typ, n = protowire.ConsumeTag(buf); n < 0 {
+				return buf, protowire.ParseError(n)
+			}
+		}
+		buf = buf[n:]
+		switch num {
+		case 1:
+			if typ != protowire.VarintType {
+				return buf, fmt.Errorf("field ID: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeVarint(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 20:
+				v.ID = protowire.DecodeZigZag(x)
+			case 8:
+				v.ID = int64(x)
+			default:
+				return buf, fmt.Errorf("field ID: unexpected data type %d", dataType)
+			}
+		case 2:
+			if typ != protowire.BytesType {
+				return buf, fmt.Errorf("field Name: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeString(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 13:
+				v.Name = x
+			default:
+				return buf, fmt.Errorf("field Name: unexpected data type %d", dataType)
+			}
+		case 3:
+			if typ != protowire.VarintType {
+				return buf, fmt.Errorf("field OK: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeVarint(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 1:
+				if x > 1 {
+					return buf, fmt.Errorf("field OK: not a bool value, %d", x)
+				}
+				v.OK = protowire.DecodeBool(x)
+			default:
+				return buf, fmt.Errorf("field OK: unexpected data type %d", dataType)
+			}
+		case 4:
+			if typ != protowire.VarintType {
+				return buf, fmt.Errorf("field Level: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeVarint(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 17:
+				v.Level = int8(protowire.DecodeZigZag(x))
+			case 2:
+				v.Level = int8(x)
+			default:
+				return buf, fmt.Errorf("field Level: unexpected data type %d", dataType)
+			}
+		case 5:
+			if typ != protowire.VarintType {
+				return buf, fmt.Errorf("field Code: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeVarint(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 3:
+				v.Code = uint8(x)
+			default:
+				return buf, fmt.Errorf("field Code: unexpected data type %d", dataType)
+			}
+		case 6:
+			if typ != protowire.VarintType {
+				return buf, fmt.Errorf("field Port: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeVarint(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 5:
+				v.Port = uint16(x)
+			default:
+				return buf, fmt.Errorf("field Port: unexpected data type %d", dataType)
+			}
+		case 7:
+			if typ != protowire.VarintType {
+				return buf, fmt.Errorf("field Delta: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeVarint(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 19:
+				v.Delta = int32(protowire.DecodeZigZag(x))
+			case 6:
+				v.Delta = int32(x)
+			default:
+				return buf, fmt.Errorf("field Delta: unexpected data type %d", dataType)
+			}
+		case 8:
+			if typ != protowire.VarintType {
+				return buf, fmt.Errorf("field Count: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeVarint(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 7:
+				v.Count = uint32(x)
+			default:
+				return buf, fmt.Errorf("field Count: unexpected data type %d", dataType)
+			}
+		case 9:
+			if typ != protowire.VarintType {
+				return buf, fmt.Errorf("field Total: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeVarint(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 9:
+				v.Total = x
+			default:
+				return buf, fmt.Errorf("field Total: unexpected data type %d", dataType)
+			}
+		case 10:
+			if typ != protowire.VarintType {
+				return buf, fmt.Errorf("field Offset: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeVarint(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 21:
+				v.Offset = int(protowire.DecodeZigZag(x))
+			case 10:
+				v.Offset = int(x)
+			default:
+				return buf, fmt.Errorf("field Offset: unexpected data type %d", dataType)
+			}
+		case 11:
+			if typ != protowire.Fixed32Type {
+				return buf, fmt.Errorf("field Ratio: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeFixed32(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 11:
+				v.Ratio = math.Float32frombits(x)
+			default:
+				return buf, fmt.Errorf("field Ratio: unexpected data type %d", dataType)
+			}
+		case 12:
+			if typ != protowire.Fixed64Type {
+				return buf, fmt.Errorf("field Value: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeFixed64(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 12:
+				v.Value = math.Float64frombits(x)
+			default:
+				return buf, fmt.Errorf("field Value: unexpected data type %d", dataType)
+			}
+		case 13:
+			if typ != protowire.BytesType {
+				return buf, fmt.Errorf("field Raw: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeBytes(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 14:
+				v.Raw = x
+			default:
+				return buf, fmt.Errorf("field Raw: unexpected data type %d", dataType)
+			}
+		case 14:
+			if typ != protowire.BytesType {
+				return buf, fmt.Errorf("field Samples: unexpected wire type %d", typ)
+			}
+			if dataType != 31 {
+				return buf, fmt.Errorf("field Samples: unexpected data type %d", dataType)
+			}
+			x, m := protowire.ConsumeBytes(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			if len(x)%8 != 0 {
+				return buf, fmt.Errorf("field Samples: packed length error, len=%d", len(x))
+			}
+			v.Samples = make([]float64, 0, len(x)/8)
+			for len(x) > 0 {
+				item, k := protowire.ConsumeFixed64(x)
+				if k < 0 {
+					return buf, protowire.ParseError(k)
+				}
+				x = x[k:]
+				v.Samples = append(v.Samples, math.Float64frombits(item))
+			}
+		case 15:
+			if typ != protowire.BytesType {
+				return buf, fmt.Errorf("field Deltas: unexpected wire type %d", typ)
+			}
+			if dataType != 27 {
+				return buf, fmt.Errorf("field Deltas: unexpected data type %d", dataType)
+			}
+			x, m := protowire.ConsumeBytes(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			count := 0
+			for _, c := range x {
+				if c < 0x80 {
+					count++
+				}
+			}
+			v.Deltas = make([]int64, 0, count)
+			for len(x) > 0 {
+				item, k := protowire.ConsumeVarint(x)
+				if k < 0 {
+					return buf, protowire.ParseError(k)
+				}
+				x = x[k:]
+				v.Deltas = append(v.Deltas, protowire.DecodeZigZag(item))
+			}
+		case 16:
+			if typ != protowire.StartGroupType {
+				return buf, fmt.Errorf("field Pos: unexpected wire type %d", typ)
+			}
+			var err error
+			if buf, err = v.Pos.ConsumeSerializer(buf); err != nil {
+				return buf, fmt.Errorf("field Pos: %w", err)
+			}
+			endNum, endTyp, m := protowire.ConsumeTag(buf)
+			if m < 0 || endNum != 16 || endTyp != protowire.EndGroupType {
+				return buf, fmt.Errorf("field Pos: group end error")
+			}
+			buf = buf[m:]
+		case 17:
+			if typ != protowire.VarintType {
+				return buf, fmt.Errorf("field Short: unexpected wire type %d", typ)
+			}
+			x, m := protowire.ConsumeVarint(buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+			switch dataType {
+			case 18:
+				v.Short = int16(protowire.DecodeZigZag(x))
+			case 4:
+				v.Short = int16(x)
+			default:
+				return buf, fmt.Errorf("field Short: unexpected data type %d", dataType)
+			}
+		default:
+			m := protowire.ConsumeFieldValue(num, typ, buf)
+			if m < 0 {
+				return buf, protowire.ParseError(m)
+			}
+			buf = buf[m:]
+		}
+	}
+	return buf, nil
+}
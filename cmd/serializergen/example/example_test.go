@@ -0,0 +1,86 @@
+package example
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ahfuzhang/serializer"
+)
+
+func getTestRow() *Row {
+	return &Row{
+		ID: -1, Name: "abc", OK: true, Level: -2, Code: 3, Port: 8080, Delta: -99, Count: 7,
+		Total: 1 << 40, Offset: -19, Ratio: 10.1, Value: -111.2, Raw: []byte("AABB"),
+		Samples: []float64{1.5, -2.5}, Deltas: []int64{-1, 0, 1},
+		Pos: Point{X: -3, Y: 4}, Short: -300,
+	}
+}
+
+func TestSameAsEncode(t *testing.T) {
+	row := getTestRow()
+	buf := serializer.AppendArrayStart(nil, 1)
+	buf = row.AppendSerializer(buf)
+	buf = serializer.AppendArrayEnd(buf, 1)
+	expected, err := serializer.Encode(nil, 1, row)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual(expected, buf) {
+		t.Errorf("not same as serializer.Encode")
+		return
+	}
+	var out Row
+	if _, err = serializer.DecodeInto(buf, &out); err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual(*row, out) {
+		t.Errorf("not equal, %+v", out)
+	}
+}
+
+func TestConsumeSerializer(t *testing.T) {
+	row := getTestRow()
+	buf, err := serializer.Encode(nil, 1, row)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	var out Row
+	arrayData, headLen, _, _, err := serializer.ReadArray(buf)
+	if err != nil {
+		t.Errorf("read array error, err=%+v", err)
+		return
+	}
+	leftData, err := out.ConsumeSerializer(arrayData[headLen:])
+	if err != nil {
+		t.Errorf("consume error, err=%+v", err)
+		return
+	}
+	if len(leftData) != 1 {
+		t.Errorf("left data should be the group end tag, len=%d", len(leftData))
+		return
+	}
+	if !reflect.DeepEqual(*row, out) {
+		t.Errorf("not equal, %+v", out)
+	}
+	// generic Decode read it as an array
+	_, values, err := serializer.Decode(buf)
+	if err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	if arr, ok := values.([]interface{}); !ok || len(arr) != 17 || arr[0] != int64(-1) {
+		t.Errorf("decode error, %+v", values)
+	}
+}
+
+func BenchmarkAppendSerializer(b *testing.B) {
+	row := getTestRow()
+	buf := make([]byte, 0, 1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = row.AppendSerializer(buf[:0])
+	}
+}
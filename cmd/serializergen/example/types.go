@@ -0,0 +1,32 @@
+// Package example is the test data of serializergen
+package example
+
+//go:generate go run github.com/ahfuzhang/serializer/cmd/serializergen -type Row,Point .
+
+// Row is a row of metrics
+type Row struct {
+	ID      int64     `serializer:"1"`
+	Name    string    `serializer:"2"`
+	OK      bool      `serializer:"3"`
+	Level   int8      `serializer:"4"`
+	Code    uint8     `serializer:"5"`
+	Port    uint16    `serializer:"6"`
+	Delta   int32     `serializer:"7"`
+	Count   uint32    `serializer:"8"`
+	Total   uint64    `serializer:"9"`
+	Offset  int       `serializer:"10"`
+	Ratio   float32   `serializer:"11"`
+	Value   float64   `serializer:"12"`
+	Raw     []byte    `serializer:"13"`
+	Samples []float64 `serializer:"14"`
+	Deltas  []int64   `serializer:"15"`
+	Pos     Point     `serializer:"16"`
+	Short   int16     `serializer:"17"`
+	Ignored string    `serializer:"-"`
+	hidden  int
+}
+
+// Point is a nested struct
+type Point struct {
+	X, Y int32
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	tagName       = "serializer"
+	tagOfDataType = 15
+)
+
+// basicType describe how to write/read a golang basic type, the data type ids must match serializer.go,
+// TestDataTypeIDs checks them against what serializer.Encode writes
+type basicType struct {
+	typeID   int
+	oldID    int    // the old signed type id without zigzag, 0 if none
+	wireType string // name of protowire.Type
+	size     int    // size of fixed value, 0 for varint
+	encode   string // format of the value to append, %s is the golang value
+	decode   string // format of the golang value, %s is the consumed value
+	decodeV1 string // format for oldID
+}
+
+var basicTypes = map[string]*basicType{
+	"bool":    {typeID: 1, wireType: "VarintType", encode: "protowire.EncodeBool(%s)", decode: "protowire.DecodeBool(%s)"},
+	"int8":    {typeID: 17, oldID: 2, wireType: "VarintType", encode: "protowire.EncodeZigZag(int64(%s))", decode: "int8(protowire.DecodeZigZag(%s))", decodeV1: "int8(%s)"},
+	"uint8":   {typeID: 3, wireType: "VarintType", encode: "uint64(%s)", decode: "uint8(%s)"},
+	"int16":   {typeID: 18, oldID: 4, wireType: "VarintType", encode: "protowire.EncodeZigZag(int64(%s))", decode: "int16(protowire.DecodeZigZag(%s))", decodeV1: "int16(%s)"},
+	"uint16":  {typeID: 5, wireType: "VarintType", encode: "uint64(%s)", decode: "uint16(%s)"},
+	"int32":   {typeID: 19, oldID: 6, wireType: "VarintType", encode: "protowire.EncodeZigZag(int64(%s))", decode: "int32(protowire.DecodeZigZag(%s))", decodeV1: "int32(%s)"},
+	"uint32":  {typeID: 7, wireType: "VarintType", encode: "uint64(%s)", decode: "uint32(%s)"},
+	"int64":   {typeID: 20, oldID: 8, wireType: "VarintType", encode: "protowire.EncodeZigZag(%s)", decode: "protowire.DecodeZigZag(%s)", decodeV1: "int64(%s)"},
+	"uint64":  {typeID: 9, wireType: "VarintType", encode: "%s", decode: "%s"},
+	"int":     {typeID: 21, oldID: 10, wireType: "VarintType", encode: "protowire.EncodeZigZag(int64(%s))", decode: "int(protowire.DecodeZigZag(%s))", decodeV1: "int(%s)"},
+	"float32": {typeID: 11, wireType: "Fixed32Type", size: 4, encode: "math.Float32bits(%s)", decode: "math.Float32frombits(%s)"},
+	"float64": {typeID: 12, wireType: "Fixed64Type", size: 8, encode: "math.Float64bits(%s)", decode: "math.Float64frombits(%s)"},
+	"string":  {typeID: 13, wireType: "BytesType", encode: "%s", decode: "%s"},
+	"[]byte":  {typeID: 14, wireType: "BytesType", encode: "%s", decode: "%s"},
+}
+
+// data type ids of packed slices, checked by TestDataTypeIDs too
+var packedTypes = map[string]int{
+	"int8":    22,
+	"int16":   23,
+	"uint16":  24,
+	"int32":   25,
+	"uint32":  26,
+	"int64":   27,
+	"uint64":  28,
+	"int":     29,
+	"float32": 30,
+	"float64": 31,
+}
+
+type fieldKind int
+
+const (
+	kindBasic fieldKind = iota
+	kindPacked
+	kindStruct
+)
+
+type field struct {
+	name     string
+	num      int
+	kind     fieldKind
+	typeName string // basic type name, element type of packed slice, or struct name
+}
+
+type structType struct {
+	name   string
+	fields []field
+}
+
+// Generate parse the package in dir, and generate the code for typeNames
+func Generate(dir string, typeNames []string) (pkgName string, src []byte, err error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return "", nil, fmt.Errorf("parse dir %s error, err=%s", dir, err.Error())
+	}
+	var pkg *ast.Package
+	for name, p := range pkgs {
+		pkgName, pkg = name, p
+	}
+	if len(pkgs) != 1 {
+		return "", nil, fmt.Errorf("need exactly one package in %s, found %d", dir, len(pkgs))
+	}
+	specs := make(map[string]*ast.StructType)
+	for _, f := range pkg.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if ts, ok := n.(*ast.TypeSpec); ok {
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					specs[ts.Name.Name] = st
+				}
+			}
+			return true
+		})
+	}
+	wanted := make(map[string]bool, len(typeNames))
+	for _, name := range typeNames {
+		wanted[strings.TrimSpace(name)] = true
+	}
+	types := make([]*structType, 0, len(wanted))
+	for name := range wanted {
+		st, ok := specs[name]
+		if !ok {
+			return "", nil, fmt.Errorf("struct type %s not found in %s", name, dir)
+		}
+		t, err := parseStruct(name, st, wanted)
+		if err != nil {
+			return "", nil, err
+		}
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].name < types[j].name })
+	src, err = generateCode(pkgName, types)
+	return pkgName, src, err
+}
+
+func parseStruct(name string, st *ast.StructType, structs map[string]bool) (*structType, error) {
+	t := &structType{name: name}
+	nums := make(map[int]string)
+	index := 0
+	for _, f := range st.Fields.List {
+		names := make([]string, 0, len(f.Names))
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+		if len(names) == 0 {
+			ident, ok := f.Type.(*ast.Ident)
+			if !ok {
+				return nil, fmt.Errorf("%s: unsupported embedded field", name)
+			}
+			names = append(names, ident.Name)
+		}
+		var tag string
+		if f.Tag != nil {
+			s, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: bad tag %s", name, f.Tag.Value)
+			}
+			tag = s
+		}
+		for _, fieldName := range names {
+			index++
+			if !ast.IsExported(fieldName) {
+				continue
+			}
+			num := index
+			if v, ok := reflect.StructTag(tag).Lookup(tagName); ok {
+				v = strings.TrimSpace(v)
+				if v == "-" {
+					continue
+				}
+				n, err := strconv.Atoi(v)
+				if err != nil || n <= 0 || n > int(protowire.MaxValidNumber) {
+					return nil, fmt.Errorf("%s.%s: invalid field number, tag=%s", name, fieldName, v)
+				}
+				num = n
+			}
+			if other, ok := nums[num]; ok {
+				return nil, fmt.Errorf("%s: duplicate field number %d of %s and %s", name, num, other, fieldName)
+			}
+			nums[num] = fieldName
+			fd, err := parseFieldType(f.Type, structs)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %s", name, fieldName, err.Error())
+			}
+			fd.name, fd.num = fieldName, num
+			t.fields = append(t.fields, fd)
+		}
+	}
+	return t, nil
+}
+
+func parseFieldType(expr ast.Expr, structs map[string]bool) (field, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if e.Name == "byte" {
+			return field{kind: kindBasic, typeName: "uint8"}, nil
+		}
+		if _, ok := basicTypes[e.Name]; ok {
+			return field{kind: kindBasic, typeName: e.Name}, nil
+		}
+		if structs[e.Name] {
+			return field{kind: kindStruct, typeName: e.Name}, nil
+		}
+		return field{}, fmt.Errorf("unsupported type %s, a struct type must be generated together", e.Name)
+	case *ast.ArrayType:
+		elem, ok := e.Elt.(*ast.Ident)
+		if e.Len == nil && ok {
+			if elem.Name == "byte" || elem.Name == "uint8" {
+				return field{kind: kindBasic, typeName: "[]byte"}, nil
+			}
+			if _, ok := packedTypes[elem.Name]; ok {
+				return field{kind: kindPacked, typeName: elem.Name}, nil
+			}
+		}
+	}
+	return field{}, fmt.Errorf("unsupported type %T", expr)
+}
+
+func generateCode(pkgName string, types []*structType) ([]byte, error) {
+	g := &bytes.Buffer{}
+	useFmt, useMath := false, false
+	for _, t := range types {
+		for _, f := range t.fields {
+			useFmt = true
+			if f.typeName == "float32" || f.typeName == "float64" {
+				useMath = true
+			}
+		}
+	}
+	fmt.Fprintf(g, "// Code generated by serializergen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(g, "package %s\n\n", pkgName)
+	fmt.Fprintf(g, "import (\n")
+	if useFmt {
+		fmt.Fprintf(g, "\"fmt\"\n")
+	}
+	if useMath {
+		fmt.Fprintf(g, "\"math\"\n")
+	}
+	fmt.Fprintf(g, "\n\"google.golang.org/protobuf/encoding/protowire\"\n)\n\n")
+	for _, t := range types {
+		generateAppend(g, t)
+		generateConsume(g, t)
+	}
+	src, err := format.Source(g.Bytes())
+	if err != nil {
+		return g.Bytes(), fmt.Errorf("format code error, err=%s", err.Error())
+	}
+	return src, nil
+}
+
+func generateAppend(g *bytes.Buffer, t *structType) {
+	fmt.Fprintf(g, "\n// AppendSerializer append fields of %s to buf, the format is same as serializer.Encode for a []interface{} row.\n", t.name)
+	fmt.Fprintf(g, "// use serializer.AppendArrayStart/AppendArrayEnd around it to make a row.\n")
+	fmt.Fprintf(g, "func (v *%s) AppendSerializer(buf []byte) []byte {\n", t.name)
+	for _, f := range t.fields {
+		value := "v." + f.name
+		switch f.kind {
+		case kindBasic:
+			b := basicTypes[f.typeName]
+			if f.typeName == "[]byte" {
+				fmt.Fprintf(g, "if %s != nil {\n", value)
+			}
+			fmt.Fprintf(g, "buf = append(buf, 0x%02x, %d) // data type: %s\n", tagOfDataType<<3, b.typeID, f.typeName)
+			fmt.Fprintf(g, "buf = protowire.AppendTag(buf, %d, protowire.%s)\n", f.num, b.wireType)
+			fmt.Fprintf(g, "buf = protowire.%s(buf, %s)\n", appendFunc(f.typeName, b), fmt.Sprintf(b.encode, value))
+			if f.typeName == "[]byte" {
+				fmt.Fprintf(g, "}\n")
+			}
+		case kindPacked:
+			b := basicTypes[f.typeName]
+			fmt.Fprintf(g, "if %s != nil {\n", value)
+			fmt.Fprintf(g, "buf = append(buf, 0x%02x, %d) // data type: []%s\n", tagOfDataType<<3, packedTypes[f.typeName], f.typeName)
+			fmt.Fprintf(g, "buf = protowire.AppendTag(buf, %d, protowire.BytesType)\n", f.num)
+			if b.size > 0 {
+				fmt.Fprintf(g, "buf = protowire.AppendVarint(buf, uint64(len(%s)*%d))\n", value, b.size)
+			} else {
+				fmt.Fprintf(g, "size := 0\nfor _, item := range %s {\nsize += protowire.SizeVarint(%s)\n}\n", value, fmt.Sprintf(b.encode, "item"))
+				fmt.Fprintf(g, "buf = protowire.AppendVarint(buf, uint64(size))\n")
+			}
+			fmt.Fprintf(g, "for _, item := range %s {\nbuf = protowire.%s(buf, %s)\n}\n", value, appendFunc(f.typeName, b), fmt.Sprintf(b.encode, "item"))
+			fmt.Fprintf(g, "}\n")
+		case kindStruct:
+			fmt.Fprintf(g, "buf = protowire.AppendTag(buf, %d, protowire.StartGroupType)\n", f.num)
+			fmt.Fprintf(g, "buf = %s.AppendSerializer(buf)\n", value)
+			fmt.Fprintf(g, "buf = protowire.AppendTag(buf, %d, protowire.EndGroupType)\n", f.num)
+		}
+	}
+	fmt.Fprintf(g, "return buf\n}\n")
+}
+
+func generateConsume(g *bytes.Buffer, t *structType) {
+	fmt.Fprintf(g, "\n// ConsumeSerializer read fields of %s from buf until the group end tag or the end of buf,\n", t.name)
+	fmt.Fprintf(g, "// it returns the data from the group end tag. bytes fields refer to buf.\n")
+	fmt.Fprintf(g, "func (v *%s) ConsumeSerializer(buf []byte) ([]byte, error) {\n", t.name)
+	fmt.Fprintf(g, `for len(buf) > 0 {
+num, typ, n := protowire.ConsumeTag(buf)
+if n < 0 {
+	return buf, protowire.ParseError(n)
+}
+if typ == protowire.EndGroupType {
+	return buf, nil
+}
+dataType := uint64(0)
+if num == %d && typ == protowire.VarintType {
+	var m int
+	dataType, m = protowire.ConsumeVarint(buf[n:])
+	if m < 0 {
+		return buf, protowire.ParseError(m)
+	}
+	buf = buf[n+m:]
+	if num, typ, n = protowire.ConsumeTag(buf); n < 0 {
+		return buf, protowire.ParseError(n)
+	}
+}
+buf = buf[n:]
+switch num {
+`, tagOfDataType)
+	for _, f := range t.fields {
+		fmt.Fprintf(g, "case %d:\n", f.num)
+		value := "v." + f.name
+		switch f.kind {
+		case kindBasic:
+			b := basicTypes[f.typeName]
+			checkWireType(g, f, b.wireType)
+			fmt.Fprintf(g, "x, m := protowire.%s(buf)\n", consumeFunc(f.typeName, b))
+			consumeError(g)
+			fmt.Fprintf(g, "switch dataType {\ncase %d:\n", b.typeID)
+			if f.typeName == "bool" {
+				fmt.Fprintf(g, "if x > 1 {\nreturn buf, fmt.Errorf(\"field %s: not a bool value, %%d\", x)\n}\n", f.name)
+			}
+			fmt.Fprintf(g, "%s = %s\n", value, fmt.Sprintf(b.decode, "x"))
+			if b.oldID != 0 {
+				fmt.Fprintf(g, "case %d:\n%s = %s\n", b.oldID, value, fmt.Sprintf(b.decodeV1, "x"))
+			}
+			dataTypeError(g, f)
+		case kindPacked:
+			b := basicTypes[f.typeName]
+			checkWireType(g, f, "BytesType")
+			fmt.Fprintf(g, "if dataType != %d {\nreturn buf, fmt.Errorf(\"field %s: unexpected data type %%d\", dataType)\n}\n", packedTypes[f.typeName], f.name)
+			fmt.Fprintf(g, "x, m := protowire.ConsumeBytes(buf)\n")
+			consumeError(g)
+			if b.size > 0 {
+				fmt.Fprintf(g, "if len(x)%%%d != 0 {\nreturn buf, fmt.Errorf(\"field %s: packed length error, len=%%d\", len(x))\n}\n", b.size, f.name)
+				fmt.Fprintf(g, "%s = make([]%s, 0, len(x)/%d)\n", value, f.typeName, b.size)
+			} else {
+				fmt.Fprintf(g, "count := 0\nfor _, c := range x {\nif c < 0x80 {\ncount++\n}\n}\n")
+				fmt.Fprintf(g, "%s = make([]%s, 0, count)\n", value, f.typeName)
+			}
+			fmt.Fprintf(g, "for len(x) > 0 {\nitem, k := protowire.%s(x)\n", consumeFunc(f.typeName, b))
+			fmt.Fprintf(g, "if k < 0 {\nreturn buf, protowire.ParseError(k)\n}\nx = x[k:]\n")
+			fmt.Fprintf(g, "%s = append(%s, %s)\n}\n", value, value, fmt.Sprintf(b.decode, "item"))
+		case kindStruct:
+			checkWireType(g, f, "StartGroupType")
+			fmt.Fprintf(g, "var err error\nif buf, err = %s.ConsumeSerializer(buf); err != nil {\nreturn buf, fmt.Errorf(\"field %s: %%w\", err)\n}\n", value, f.name)
+			fmt.Fprintf(g, "endNum, endTyp, m := protowire.ConsumeTag(buf)\n")
+			fmt.Fprintf(g, "if m < 0 || endNum != %d || endTyp != protowire.EndGroupType {\nreturn buf, fmt.Errorf(\"field %s: group end error\")\n}\n", f.num, f.name)
+			fmt.Fprintf(g, "buf = buf[m:]\n")
+		}
+	}
+	fmt.Fprintf(g, `default:
+	m := protowire.ConsumeFieldValue(num, typ, buf)
+	if m < 0 {
+		return buf, protowire.ParseError(m)
+	}
+	buf = buf[m:]
+}
+}
+return buf, nil
+}
+`)
+}
+
+func checkWireType(g *bytes.Buffer, f field, wireType string) {
+	fmt.Fprintf(g, "if typ != protowire.%s {\nreturn buf, fmt.Errorf(\"field %s: unexpected wire type %%d\", typ)\n}\n", wireType, f.name)
+}
+
+func consumeError(g *bytes.Buffer) {
+	fmt.Fprintf(g, "if m < 0 {\nreturn buf, protowire.ParseError(m)\n}\nbuf = buf[m:]\n")
+}
+
+func dataTypeError(g *bytes.Buffer, f field) {
+	fmt.Fprintf(g, "default:\nreturn buf, fmt.Errorf(\"field %s: unexpected data type %%d\", dataType)\n}\n", f.name)
+}
+
+func appendFunc(typeName string, b *basicType) string {
+	switch {
+	case typeName == "string":
+		return "AppendString"
+	case typeName == "[]byte":
+		return "AppendBytes"
+	case b.size == 4:
+		return "AppendFixed32"
+	case b.size == 8:
+		return "AppendFixed64"
+	}
+	return "AppendVarint"
+}
+
+func consumeFunc(typeName string, b *basicType) string {
+	switch {
+	case typeName == "string":
+		return "ConsumeString"
+	case typeName == "[]byte":
+		return "ConsumeBytes"
+	case b.size == 4:
+		return "ConsumeFixed32"
+	case b.size == 8:
+		return "ConsumeFixed64"
+	}
+	return "ConsumeVarint"
+}
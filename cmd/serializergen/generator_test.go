@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer"
+)
+
+// the generated code of example is the golden file, run `go generate ./example` after changing the generator
+func TestGenerateGolden(t *testing.T) {
+	_, src, err := Generate("example", []string{"Row", "Point"})
+	if err != nil {
+		t.Errorf("generate error, err=%+v", err)
+		return
+	}
+	golden, err := os.ReadFile("example/example_serializer.go")
+	if err != nil {
+		t.Errorf("read golden file error, err=%+v", err)
+		return
+	}
+	if !bytes.Equal(golden, src) {
+		t.Errorf("generated code not match example/example_serializer.go")
+	}
+}
+
+func TestGenerateError(t *testing.T) {
+	if _, _, err := Generate("example", []string{"NotExists"}); err == nil {
+		t.Errorf("should fail for a not exists type")
+	}
+	if _, _, err := Generate("example", []string{"Row"}); err == nil {
+		t.Errorf("should fail if the nested struct is not generated")
+	}
+}
+
+// encodedTypeID read the data type field written by serializer.Encode
+func encodedTypeID(t *testing.T, v interface{}) int {
+	buf, err := serializer.Encode(nil, 1, v)
+	if err != nil {
+		t.Fatalf("encode %T error, err=%+v", v, err)
+	}
+	num, typ, n := protowire.ConsumeTag(buf)
+	if n < 0 || num != tagOfDataType || typ != protowire.VarintType {
+		t.Fatalf("encode %T without data type field", v)
+	}
+	id, m := protowire.ConsumeVarint(buf[n:])
+	if m < 0 {
+		t.Fatalf("read data type of %T error,code=%d", v, m)
+	}
+	return int(id)
+}
+
+// the data type ids of generator are copied from serializer.go, make sure they do not drift
+func TestDataTypeIDs(t *testing.T) {
+	samples := map[string]interface{}{
+		"bool":    false,
+		"int8":    int8(0),
+		"uint8":   uint8(0),
+		"int16":   int16(0),
+		"uint16":  uint16(0),
+		"int32":   int32(0),
+		"uint32":  uint32(0),
+		"int64":   int64(0),
+		"uint64":  uint64(0),
+		"int":     0,
+		"float32": float32(0),
+		"float64": float64(0),
+		"string":  "",
+		"[]byte":  []byte{},
+	}
+	if len(samples) != len(basicTypes) {
+		t.Fatalf("samples not match basicTypes, %d != %d", len(samples), len(basicTypes))
+	}
+	for name, bt := range basicTypes {
+		sample := samples[name]
+		if id := encodedTypeID(t, sample); id != bt.typeID {
+			t.Errorf("data type of %s is %d, serializer writes %d", name, bt.typeID, id)
+		}
+		if bt.oldID == 0 {
+			continue
+		}
+		// the old signed type id must still be decoded as the same type
+		buf := protowire.AppendTag(nil, tagOfDataType, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, uint64(bt.oldID))
+		buf = protowire.AppendTag(buf, 1, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, 1)
+		_, value, err := serializer.Decode(buf)
+		if err != nil || reflect.TypeOf(value) != reflect.TypeOf(sample) {
+			t.Errorf("old data type %d of %s decoded as %T, err=%+v", bt.oldID, name, value, err)
+		}
+	}
+	for name, id := range packedTypes {
+		slice := reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(samples[name])), 1, 1).Interface()
+		if got := encodedTypeID(t, slice); got != id {
+			t.Errorf("data type of []%s is %d, serializer writes %d", name, id, got)
+		}
+	}
+}
+
+func TestParseStructFieldNumber(t *testing.T) {
+	src := "package p\ntype T struct {\n\tA int `serializer:\"536870912\"`\n}\n"
+	f, err := parser.ParseFile(token.NewFileSet(), "p.go", src, 0)
+	if err != nil {
+		t.Fatalf("parse error, err=%+v", err)
+	}
+	st := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	if _, err = parseStruct("T", st, nil); err == nil {
+		t.Errorf("should fail for a field number above protowire.MaxValidNumber")
+	}
+}
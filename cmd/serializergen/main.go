@@ -0,0 +1,42 @@
+// serializergen generate AppendSerializer/ConsumeSerializer methods for structs,
+// the binary format is same as serializer.Encode for []interface{} rows, without interface{} boxing.
+//
+// usage:
+//
+//	serializergen -type Row,Item [-output row_serializer.go] [dir]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of struct type names, must be set")
+	output := flag.String("output", "", "output file name, default is <package>_serializer.go in dir")
+	flag.Parse()
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	pkgName, src, err := Generate(dir, strings.Split(*typeNames, ","))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "serializergen: %s\n", err.Error())
+		os.Exit(1)
+	}
+	outFile := *output
+	if outFile == "" {
+		outFile = filepath.Join(dir, pkgName+"_serializer.go")
+	}
+	if err = os.WriteFile(outFile, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "serializergen: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,191 @@
+package serializer
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+const tagOfRowCount = 1
+
+// EncodeColumns encode rows in column-major order, the data type of each column is written once,
+// then the values of the column back to back without data type field.
+// a value which type is different from its column, or the value of row 15, still has its own data type field.
+// all rows must have the same count of columns, and at least one column if rows is not empty.
+func EncodeColumns(buf []byte, tag int, rows [][]interface{}) ([]byte, error) {
+	colCount := 0
+	if len(rows) > 0 {
+		colCount = len(rows[0])
+	}
+	if len(rows) > 0 && colCount == 0 {
+		// the row count must be confirmed by the columns when decoding
+		return buf, fmt.Errorf("[%s]%d rows without columns", debugs.SourceCodeLoc(1), len(rows))
+	}
+	for idx, row := range rows {
+		if len(row) != colCount {
+			return buf, fmt.Errorf("[%s]row %d has %d columns, expect %d", debugs.SourceCodeLoc(1), idx, len(row), colCount)
+		}
+	}
+	buf = setType(buf, tColumns)
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.StartGroupType)
+	buf = protowire.AppendTag(buf, tagOfRowCount, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, uint64(len(rows)))
+	var (
+		scratch []byte
+		err     error
+	)
+	for col := 0; col < colCount; col++ {
+		// the type of first value is the type of column
+		scratch, err = Encode(scratch[:0], 1, rows[0][col])
+		if err != nil {
+			return buf, debugs.WarpError(err, fmt.Sprintf("encode column %d error", col))
+		}
+		colType, _, typ, n := consumeHead(scratch)
+		if n < 0 {
+			return buf, fmt.Errorf("[%s]read column type error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		if typ == protowire.StartGroupType {
+			colType = 0 // array or map keep the data type field, so they never be confused
+		}
+		if colType != 0 {
+			buf = setType(buf, colType)
+		}
+		buf = protowire.AppendTag(buf, protowire.Number(col+1), protowire.StartGroupType)
+		for idx, row := range rows {
+			start := len(buf)
+			buf, err = Encode(buf, idx+1, row[col])
+			if err != nil {
+				return buf, debugs.WarpError(err, fmt.Sprintf("encode row %d column %d error", idx, col))
+			}
+			// a varint with tag 15 is the same bytes as the data type field, so row 15 keeps its data type
+			if colType != 0 && idx+1 != tagOfDataType {
+				buf = trimType(buf, start, colType)
+			}
+		}
+		buf = protowire.AppendTag(buf, protowire.Number(col+1), protowire.EndGroupType)
+	}
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.EndGroupType)
+	return buf, nil
+}
+
+// trimType remove the data type field at buf[start:] if it is the type t
+func trimType(buf []byte, start int, t uint64) []byte {
+	golangType, _, _, n := consumeHead(buf[start:])
+	if n < 0 || golangType != t {
+		return buf
+	}
+	typeLen := protowire.SizeTag(tagOfDataType) + protowire.SizeVarint(t)
+	copy(buf[start:], buf[start+typeLen:])
+	return buf[:len(buf)-typeLen]
+}
+
+// decodeColumns decode column-major rows after the group start tag, return rows as []interface{}
+func (d *decoder) decodeColumns(buf []byte) ([]byte, interface{}, error) {
+	num, typ, n := protowire.ConsumeTag(buf)
+	if n < 0 || num != tagOfRowCount || typ != protowire.VarintType {
+		return buf, nil, fmt.Errorf("[%s]read row count error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	count, m := protowire.ConsumeVarint(buf[n:])
+	if m < 0 {
+		return buf, nil, fmt.Errorf("[%s]read row count error,code=%d", debugs.SourceCodeLoc(1), m)
+	}
+	buf = buf[n+m:]
+	if err := d.addElements(count, saturatingMul(count, sizeOfInterface)); err != nil {
+		return buf, nil, err
+	}
+	var rows []interface{}
+	for len(buf) > 0 {
+		colType, _, typ, headLen := consumeHead(buf)
+		if headLen < 0 {
+			return buf, nil, fmt.Errorf("[%s]read column error,code=%d", debugs.SourceCodeLoc(1), headLen)
+		}
+		if typ == protowire.EndGroupType {
+			buf = buf[headLen:]
+			break
+		}
+		if typ != protowire.StartGroupType {
+			return buf, nil, fmt.Errorf("[%s]column not a group, type=%d", debugs.SourceCodeLoc(1), typ)
+		}
+		leftData, values, err := d.decodeItems(buf[headLen:], colType)
+		if err != nil {
+			return buf, nil, debugs.WarpError(err, "decode column error")
+		}
+		buf = leftData
+		if uint64(len(values)) != count {
+			return buf, nil, fmt.Errorf("[%s]column has %d values, expect %d", debugs.SourceCodeLoc(1), len(values), count)
+		}
+		if rows == nil {
+			// the count is confirmed by the first column
+			rows = make([]interface{}, count)
+			for idx := range rows {
+				rows[idx] = make([]interface{}, 0, defaultArrayCount)
+			}
+		}
+		for idx, value := range values {
+			rows[idx] = append(rows[idx].([]interface{}), value)
+		}
+	}
+	if rows == nil {
+		// no columns, the count is not confirmed by any data
+		if count != 0 {
+			return buf, nil, fmt.Errorf("[%s]no columns for %d rows", debugs.SourceCodeLoc(1), count)
+		}
+		rows = []interface{}{}
+	}
+	return buf, rows, nil
+}
+
+// ReadColumns read rows encoded by EncodeColumns, send each row to callback, tag of row is its index + 1
+func ReadColumns(buf []byte, callback RowCallback) error {
+	_, values, err := Decode(buf)
+	if err != nil {
+		return debugs.WarpError(err, "Decode error")
+	}
+	rows, ok := values.([]interface{})
+	if !ok {
+		return fmt.Errorf("[%s]not rows, %T", debugs.SourceCodeLoc(1), values)
+	}
+	for idx, row := range rows {
+		cols, ok := row.([]interface{})
+		if !ok {
+			return fmt.Errorf("[%s]row %d not a []interface{}, %T", debugs.SourceCodeLoc(1), idx, row)
+		}
+		if err = callback(idx+1, cols...); err != nil {
+			return debugs.WarpError(err, "callback error")
+		}
+	}
+	return nil
+}
+
+// Transpose convert row-major buffer(encoded by Encode with a [][]interface{}) to column-major(encoded by EncodeColumns),
+// or column-major to row-major, the result is appended to dst in FormatV1
+func Transpose(dst []byte, src []byte) ([]byte, error) {
+	src, err := toV1(src)
+	if err != nil {
+		return dst, debugs.WarpError(err, "read format header error")
+	}
+	golangType, tag, _, n := consumeHead(src)
+	if n < 0 {
+		return dst, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	_, values, err := Decode(src)
+	if err != nil {
+		return dst, debugs.WarpError(err, "Decode error")
+	}
+	rows, ok := values.([]interface{})
+	if !ok {
+		return dst, fmt.Errorf("[%s]not rows, %T", debugs.SourceCodeLoc(1), values)
+	}
+	if golangType == tColumns {
+		return Encode(dst, int(tag), rows)
+	}
+	table := make([][]interface{}, len(rows))
+	for idx, row := range rows {
+		if table[idx], ok = row.([]interface{}); !ok {
+			return dst, fmt.Errorf("[%s]row %d not a []interface{}, %T", debugs.SourceCodeLoc(1), idx, row)
+		}
+	}
+	return EncodeColumns(dst, int(tag), table)
+}
@@ -0,0 +1,189 @@
+package serializer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func getTestRows() [][]interface{} {
+	arr := getTestData()
+	rows := make([][]interface{}, 0, 3)
+	for _, row := range arr[:3] {
+		rows = append(rows, row.([]interface{}))
+	}
+	return rows
+}
+
+func TestEncodeColumns(t *testing.T) {
+	rows := getTestRows()
+	rows[1][2] = "mixed type" // a value different from its column
+	buf, err := EncodeColumns(nil, 1, rows)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	rowMajor, err := Encode(nil, 1, rows)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	if len(buf) >= len(rowMajor) {
+		t.Errorf("columnar should be smaller, %d >= %d", len(buf), len(rowMajor))
+	}
+	//
+	var got [][]interface{}
+	err = ReadColumns(buf, func(tag int, cols ...interface{}) error {
+		if tag != len(got)+1 {
+			t.Errorf("tag error, tag=%d", tag)
+		}
+		got = append(got, cols)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("read error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual(rows, got) {
+		t.Errorf("not equal, %+v", got)
+		return
+	}
+	// Decode return the same value as row-major
+	_, values, err := Decode(buf)
+	if err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	_, expected, _ := Decode(rowMajor)
+	if !reflect.DeepEqual(expected, values) {
+		t.Errorf("not equal, %+v", values)
+	}
+}
+
+func TestTranspose(t *testing.T) {
+	rows := getTestRows()
+	rowMajor, err := Encode(nil, 1, rows)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	columns, err := Transpose(nil, rowMajor)
+	if err != nil {
+		t.Errorf("transpose error, err=%+v", err)
+		return
+	}
+	expected, _ := EncodeColumns(nil, 1, rows)
+	if !reflect.DeepEqual(expected, columns) {
+		t.Errorf("transpose to columns error")
+		return
+	}
+	back, err := Transpose(nil, columns)
+	if err != nil {
+		t.Errorf("transpose error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual(rowMajor, back) {
+		t.Errorf("transpose to rows error")
+	}
+}
+
+func TestEncodeColumnsEmpty(t *testing.T) {
+	for _, rows := range [][][]interface{}{{}, {{1}, {2}}} {
+		buf, err := EncodeColumns(nil, 1, rows)
+		if err != nil {
+			t.Errorf("encode error, err=%+v", err)
+			return
+		}
+		_, values, err := Decode(buf)
+		if err != nil {
+			t.Errorf("decode error, err=%+v", err)
+			return
+		}
+		if len(values.([]interface{})) != len(rows) {
+			t.Errorf("row count error, %+v", values)
+		}
+	}
+	if _, err := EncodeColumns(nil, 1, [][]interface{}{{1}, {1, 2}}); err == nil {
+		t.Errorf("rows with different columns should fail")
+	}
+	if _, err := EncodeColumns(nil, 1, [][]interface{}{{}, {}}); err == nil {
+		t.Errorf("rows without columns should fail")
+	}
+}
+
+// a row count not confirmed by any column must not be allocated
+func TestDecodeColumnsHugeCount(t *testing.T) {
+	buf := setType(nil, tColumns)
+	buf = protowire.AppendTag(buf, 1, protowire.StartGroupType)
+	buf = protowire.AppendTag(buf, tagOfRowCount, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, 1<<40)
+	buf = protowire.AppendTag(buf, 1, protowire.EndGroupType)
+	if !bytes.Equal(buf, []byte{0x78, 0x20, 0x0b, 0x08, 0x80, 0x80, 0x80, 0x80, 0x80, 0x20, 0x0c}) {
+		t.Errorf("buffer error, % x", buf)
+	}
+	if _, _, err := Decode(buf); err == nil {
+		t.Errorf("decode should fail")
+	}
+	if err := ReadColumns(buf, func(tag int, cols ...interface{}) error { return nil }); err == nil {
+		t.Errorf("ReadColumns should fail")
+	}
+	if _, err := Transpose(nil, buf); err == nil {
+		t.Errorf("Transpose should fail")
+	}
+	// a column with less values than the count
+	buf, _ = EncodeColumns(nil, 1, [][]interface{}{{1}, {2}})
+	buf[4] = 3 // count
+	if _, _, err := Decode(buf); err == nil {
+		t.Errorf("decode should fail")
+	}
+}
+
+// a trimmed varint of row 15 is the same bytes as the data type field
+func TestEncodeColumnsManyRows(t *testing.T) {
+	rows := make([][]interface{}, 0, 20)
+	for i := 0; i < 20; i++ {
+		rows = append(rows, []interface{}{int64(i - 10), i%2 == 0, time.Duration(i) * time.Second, nil, i})
+	}
+	buf, err := EncodeColumns(nil, 1, rows)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	if err = Validate(buf, ValidateOptions{}); err != nil {
+		t.Errorf("should be valid, err=%+v", err)
+	}
+	var got [][]interface{}
+	err = ReadColumns(buf, func(tag int, cols ...interface{}) error {
+		got = append(got, cols)
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(rows, got) {
+		t.Errorf("not equal, got=%+v, err=%+v", got, err)
+		return
+	}
+	v2, _, err := convertValue(AppendHeader(nil, FormatV2), buf, FormatV1, FormatV2)
+	if err != nil {
+		t.Errorf("convert to V2 error, err=%+v", err)
+		return
+	}
+	if err = Validate(v2, ValidateOptions{}); err != nil {
+		t.Errorf("V2 should be valid, err=%+v", err)
+	}
+	_, values, err := Decode(v2)
+	_, expected, _ := Decode(buf)
+	if err != nil || !reflect.DeepEqual(expected, values) {
+		t.Errorf("V2 not equal, values=%+v, err=%+v", values, err)
+	}
+	rowMajor, _ := Encode(nil, 1, rows)
+	back, err := Transpose(nil, v2)
+	if err != nil || !reflect.DeepEqual(rowMajor, back) {
+		t.Errorf("transpose to rows error, err=%+v", err)
+	}
+	columns, err := Transpose(nil, rowMajor)
+	if err != nil || !reflect.DeepEqual(buf, columns) {
+		t.Errorf("transpose to columns error, err=%+v", err)
+	}
+}
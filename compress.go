@@ -0,0 +1,199 @@
+package serializer
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// Compressor is a block codec for the compression frame
+type Compressor interface {
+	// Compress append the compressed src to dst
+	Compress(dst []byte, src []byte, level int) ([]byte, error)
+	// Decompress append the decompressed src to dst,
+	// it must fail as soon as the decompressed data is more than limit bytes
+	Decompress(dst []byte, src []byte, limit int) ([]byte, error)
+}
+
+// CompressorFlate is the id of compress/flate codec
+const CompressorFlate = 1
+
+const maxDecompressPrealloc = 16 * 1024 * 1024
+
+var (
+	compressorsLock sync.RWMutex
+	compressors     = map[uint64]Compressor{
+		CompressorFlate: &flateCompressor{},
+	}
+)
+
+// RegisterCompressor register a codec for the compression frame, id must be unique
+func RegisterCompressor(id uint64, c Compressor) error {
+	compressorsLock.Lock()
+	defer compressorsLock.Unlock()
+	if _, ok := compressors[id]; ok {
+		return fmt.Errorf("[%s]compressor %d already registered", debugs.SourceCodeLoc(1), id)
+	}
+	compressors[id] = c
+	return nil
+}
+
+func getCompressor(id uint64) (Compressor, error) {
+	compressorsLock.RLock()
+	c, ok := compressors[id]
+	compressorsLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("[%s]compressor %d not registered", debugs.SourceCodeLoc(1), id)
+	}
+	return c, nil
+}
+
+// CompressArray compress an encoded array with compress/flate, and append the compression frame to dst.
+// level is the flate level, like flate.BestSpeed.
+func CompressArray(dst []byte, src []byte, level int) ([]byte, error) {
+	return CompressArrayWith(dst, src, CompressorFlate, level)
+}
+
+// CompressArrayWith compress an encoded array with a registered codec, and append the compression frame to dst.
+// the frame is a data type field(34) and a bytes field with the tag of array,
+// the content is: codec id varint, length of src varint, compressed src.
+func CompressArrayWith(dst []byte, src []byte, codec uint64, level int) ([]byte, error) {
+	c, err := getCompressor(codec)
+	if err != nil {
+		return dst, err
+	}
+	_, body, err := ParseHeader(src)
+	if err != nil {
+		return dst, debugs.WarpError(err, "ParseHeader error")
+	}
+	_, tag, _, n := consumeHead(body)
+	if n < 0 {
+		return dst, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	buf := protowire.AppendVarint(nil, codec)
+	buf = protowire.AppendVarint(buf, uint64(len(src)))
+	if buf, err = c.Compress(buf, src, level); err != nil {
+		return dst, debugs.WarpError(err, "Compress error")
+	}
+	dst = setType(dst, tCompressed)
+	dst = protowire.AppendTag(dst, tag, protowire.BytesType)
+	dst = protowire.AppendBytes(dst, buf)
+	return dst, nil
+}
+
+// decompressFrame decompress the content of a compression frame
+func decompressFrame(data []byte) ([]byte, error) {
+	codec, n := protowire.ConsumeVarint(data)
+	if n < 0 {
+		return nil, fmt.Errorf("[%s]read codec error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	data = data[n:]
+	rawLen, n := protowire.ConsumeVarint(data)
+	if n < 0 {
+		return nil, fmt.Errorf("[%s]read raw length error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	data = data[n:]
+	c, err := getCompressor(codec)
+	if err != nil {
+		return nil, err
+	}
+	if rawLen > math.MaxInt32 {
+		return nil, fmt.Errorf("[%s]raw length %d is too large", debugs.SourceCodeLoc(1), rawLen)
+	}
+	size := rawLen
+	if size > maxDecompressPrealloc {
+		size = maxDecompressPrealloc
+	}
+	out, err := c.Decompress(make([]byte, 0, size), data, int(rawLen))
+	if err != nil {
+		return nil, debugs.WarpError(err, "Decompress error")
+	}
+	if uint64(len(out)) != rawLen {
+		return nil, fmt.Errorf("[%s]decompressed length %d not match %d", debugs.SourceCodeLoc(1), len(out), rawLen)
+	}
+	return out, nil
+}
+
+// plainData remove the header and decompress the compression frame, return the V1 data,
+// the nesting level and the decompressed length are checked with the limits of d
+func (d *decoder) plainData(buf []byte) ([]byte, error) {
+	if version, body, err := ParseHeader(buf); err == nil && version != FormatV1 {
+		if err = d.checkDepth(body, version); err != nil {
+			return buf, err
+		}
+	}
+	buf, err := toV1(buf)
+	if err != nil {
+		return buf, debugs.WarpError(err, "read format header error")
+	}
+	golangType, _, typ, n := consumeHead(buf)
+	if n < 0 || golangType != tCompressed || typ != protowire.BytesType {
+		return buf, nil
+	}
+	data, dataLen := protowire.ConsumeBytes(buf[n:])
+	if dataLen < 0 {
+		return buf, fmt.Errorf("[%s]read compression frame error,code=%d", debugs.SourceCodeLoc(1), dataLen)
+	}
+	raw, err := d.decompressFrame(data)
+	if err != nil {
+		return buf, debugs.WarpError(err, "decompressFrame error")
+	}
+	return d.plainData(raw)
+}
+
+type flateCompressor struct {
+	writers [flate.BestCompression - flate.HuffmanOnly + 1]sync.Pool // index is level - HuffmanOnly
+	readers sync.Pool
+}
+
+func (f *flateCompressor) Compress(dst []byte, src []byte, level int) ([]byte, error) {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		return dst, fmt.Errorf("[%s]invalid flate level %d", debugs.SourceCodeLoc(1), level)
+	}
+	out := bytes.NewBuffer(dst)
+	pool := &f.writers[level-flate.HuffmanOnly]
+	w, _ := pool.Get().(*flate.Writer)
+	if w == nil {
+		var err error
+		if w, err = flate.NewWriter(out, level); err != nil {
+			return dst, err
+		}
+	} else {
+		w.Reset(out)
+	}
+	defer pool.Put(w)
+	if _, err := w.Write(src); err != nil {
+		return dst, err
+	}
+	if err := w.Close(); err != nil {
+		return dst, err
+	}
+	return out.Bytes(), nil
+}
+
+func (f *flateCompressor) Decompress(dst []byte, src []byte, limit int) ([]byte, error) {
+	r, _ := f.readers.Get().(io.ReadCloser)
+	if r == nil {
+		r = flate.NewReader(bytes.NewReader(src))
+	} else if err := r.(flate.Resetter).Reset(bytes.NewReader(src), nil); err != nil {
+		return dst, err
+	}
+	defer f.readers.Put(r)
+	out := bytes.NewBuffer(dst)
+	// read one more byte to know the data is more than limit
+	n, err := io.Copy(out, io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return dst, err
+	}
+	if n > int64(limit) {
+		return dst, fmt.Errorf("[%s]decompressed data is more than %d bytes", debugs.SourceCodeLoc(1), limit)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,140 @@
+package serializer
+
+import (
+	"compress/flate"
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestCompressArray(t *testing.T) {
+	rows := make([]interface{}, 0, 100)
+	for i := 0; i < 100; i++ {
+		rows = append(rows, []interface{}{i, "abc", float64(i) / 3, []byte("AABB")})
+	}
+	src, err := EncodeVersion(nil, 1, rows, FormatV2)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	buf, err := CompressArray(nil, src, flate.BestCompression)
+	if err != nil {
+		t.Errorf("compress error, err=%+v", err)
+		return
+	}
+	if len(buf) >= len(src) {
+		t.Errorf("compressed size %d >= %d", len(buf), len(src))
+	}
+	_, values, err := Decode(buf)
+	if err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual(rows, values) {
+		t.Errorf("not equal, %+v", values)
+		return
+	}
+	count := 0
+	err = ReadEachRow(buf, func(tag int, cols ...interface{}) error {
+		if !reflect.DeepEqual(rows[count], cols) {
+			t.Errorf("row %d not equal, %+v", count, cols)
+		}
+		count++
+		return nil
+	})
+	if err != nil || count != len(rows) {
+		t.Errorf("read error, count=%d, err=%+v", count, err)
+		return
+	}
+	// a compressed cell in array
+	arr, err := Encode(nil, 1, []interface{}{"x"})
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	buf = AppendArrayStart(nil, 1)
+	if buf, err = CompressArray(buf, arr, flate.BestSpeed); err != nil {
+		t.Errorf("compress error, err=%+v", err)
+		return
+	}
+	buf = AppendArrayEnd(buf, 1)
+	_, values, err = Decode(buf)
+	if err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual([]interface{}{[]interface{}{"x"}}, values) {
+		t.Errorf("not equal, %+v", values)
+	}
+}
+
+type testCompressor struct{}
+
+func (testCompressor) Compress(dst []byte, src []byte, level int) ([]byte, error) {
+	return append(dst, src...), nil
+}
+
+func (testCompressor) Decompress(dst []byte, src []byte, limit int) ([]byte, error) {
+	if len(src) > limit {
+		return dst, fmt.Errorf("more than %d bytes", limit)
+	}
+	return append(dst, src...), nil
+}
+
+func TestRegisterCompressor(t *testing.T) {
+	if err := RegisterCompressor(CompressorFlate, testCompressor{}); err == nil {
+		t.Errorf("duplicate compressor should fail")
+	}
+	if err := RegisterCompressor(100, testCompressor{}); err != nil {
+		t.Errorf("register error, err=%+v", err)
+		return
+	}
+	t.Cleanup(func() {
+		compressorsLock.Lock()
+		delete(compressors, 100)
+		compressorsLock.Unlock()
+	})
+	src, _ := Encode(nil, 1, []interface{}{1, 2})
+	buf, err := CompressArrayWith(nil, src, 100, 0)
+	if err != nil {
+		t.Errorf("compress error, err=%+v", err)
+		return
+	}
+	if _, values, err := Decode(buf); err != nil || !reflect.DeepEqual([]interface{}{1, 2}, values) {
+		t.Errorf("decode error, values=%+v, err=%+v", values, err)
+	}
+	if _, err = CompressArrayWith(nil, src, 101, 0); err == nil {
+		t.Errorf("not registered compressor should fail")
+	}
+}
+
+// a frame which declares a small raw length must not inflate all its data
+func TestDecompressFrameLimit(t *testing.T) {
+	zeros := make([]byte, 64*1024*1024)
+	compressed, err := (&flateCompressor{}).Compress(nil, zeros, flate.BestSpeed)
+	if err != nil {
+		t.Errorf("compress error, err=%+v", err)
+		return
+	}
+	frame := protowire.AppendVarint(nil, CompressorFlate)
+	frame = protowire.AppendVarint(frame, 10)
+	frame = append(frame, compressed...)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err = decompressFrame(frame); err == nil {
+		t.Errorf("should fail for more data than the raw length")
+	}
+	runtime.ReadMemStats(&after)
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 1024*1024 {
+		t.Errorf("allocated %d bytes for a raw length of 10", alloc)
+	}
+	buf := setType(nil, tCompressed)
+	buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+	buf = protowire.AppendBytes(buf, frame)
+	if _, _, err = Decode(buf); err == nil {
+		t.Errorf("decode should fail")
+	}
+}
@@ -0,0 +1,182 @@
+package serializer
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// MinUserTypeID is the smallest id for RegisterType, the ids less than it are reserved for the built-in types
+const MinUserTypeID = 256
+
+// Marshaler is implemented by the types which write their fields directly, like the code of serializergen.
+// the fields are written in a group, the same as a struct encoded by Encode.
+type Marshaler interface {
+	// AppendSerializer append the fields to buf, without the group tags
+	AppendSerializer(buf []byte) []byte
+}
+
+// Unmarshaler is implemented by the types which read their fields directly, like the code of serializergen.
+type Unmarshaler interface {
+	// ConsumeSerializer read the fields until the group end tag, return the data from the end tag
+	ConsumeSerializer(buf []byte) ([]byte, error)
+}
+
+// EncodeFunc append the content of v to buf, v is a value of the registered type
+type EncodeFunc func(buf []byte, v interface{}) ([]byte, error)
+
+// DecodeFunc decode the content written by EncodeFunc, and return a value of the registered type
+type DecodeFunc func(data []byte) (interface{}, error)
+
+var (
+	marshalerType   = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+)
+
+type customType struct {
+	id  uint64
+	typ reflect.Type
+	enc EncodeFunc // nil means the type implements Marshaler and Unmarshaler
+	dec DecodeFunc
+}
+
+var (
+	customTypesLock sync.RWMutex
+	customTypesByID = map[uint64]*customType{}
+	customTypes     = map[reflect.Type]*customType{}
+)
+
+// RegisterType register a type with its own data type id, then Decode return the values as the type.
+// with enc and dec, the value is a bytes field which content is written by enc.
+// with nil enc and dec, the type must implement Marshaler and *T must implement Unmarshaler,
+// the value is a group of its fields.
+// id must not be less than MinUserTypeID, both id and type can be registered only once.
+func RegisterType(id uint64, sample interface{}, enc EncodeFunc, dec DecodeFunc) error {
+	if id < MinUserTypeID {
+		return fmt.Errorf("[%s]type id %d is reserved, must not be less than %d", debugs.SourceCodeLoc(1), id, MinUserTypeID)
+	}
+	if sample == nil {
+		return fmt.Errorf("[%s]sample of type %d is nil", debugs.SourceCodeLoc(1), id)
+	}
+	typ := reflect.TypeOf(sample)
+	if typ.Name() == "" || typ.PkgPath() == "" {
+		return fmt.Errorf("[%s]%s is not a defined type of a package", debugs.SourceCodeLoc(1), typ)
+	}
+	if (enc == nil) != (dec == nil) {
+		return fmt.Errorf("[%s]enc and dec of %s must be both set or both nil", debugs.SourceCodeLoc(1), typ)
+	}
+	if enc == nil {
+		if typ.Kind() == reflect.Ptr {
+			return fmt.Errorf("[%s]register %s without pointer to use Marshaler", debugs.SourceCodeLoc(1), typ)
+		}
+		ptr := reflect.PtrTo(typ)
+		if !ptr.Implements(marshalerType) || !ptr.Implements(unmarshalerType) {
+			return fmt.Errorf("[%s]%s not implement Marshaler and Unmarshaler", debugs.SourceCodeLoc(1), typ)
+		}
+	}
+	customTypesLock.Lock()
+	defer customTypesLock.Unlock()
+	if old, ok := customTypesByID[id]; ok {
+		return fmt.Errorf("[%s]type id %d already registered by %s", debugs.SourceCodeLoc(1), id, old.typ)
+	}
+	if old, ok := customTypes[typ]; ok {
+		return fmt.Errorf("[%s]%s already registered with type id %d", debugs.SourceCodeLoc(1), typ, old.id)
+	}
+	t := &customType{id: id, typ: typ, enc: enc, dec: dec}
+	customTypesByID[id] = t
+	customTypes[typ] = t
+	return nil
+}
+
+func getCustomType(typ reflect.Type) *customType {
+	customTypesLock.RLock()
+	t := customTypes[typ]
+	customTypesLock.RUnlock()
+	return t
+}
+
+func getCustomTypeByID(id uint64) *customType {
+	customTypesLock.RLock()
+	t := customTypesByID[id]
+	customTypesLock.RUnlock()
+	return t
+}
+
+// encodeCustom encode v if it is a registered type, a pointer to it, or a Marshaler, ok is false if not
+func encodeCustom(buf []byte, tag int, v interface{}) (out []byte, ok bool, err error) {
+	typ := reflect.TypeOf(v)
+	t := getCustomType(typ)
+	if t == nil && typ.Kind() == reflect.Ptr {
+		// a nil pointer is encoded as null with the id, see isNil
+		if t = getCustomType(typ.Elem()); t != nil {
+			v = reflect.ValueOf(v).Elem().Interface()
+		}
+	}
+	if t == nil {
+		if m, isMarshaler := v.(Marshaler); isMarshaler {
+			buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.StartGroupType)
+			buf = m.AppendSerializer(buf)
+			return protowire.AppendTag(buf, protowire.Number(tag), protowire.EndGroupType), true, nil
+		}
+		return buf, false, nil
+	}
+	buf = setType(buf, t.id)
+	if t.enc == nil {
+		ptr := reflect.New(t.typ)
+		ptr.Elem().Set(reflect.ValueOf(v))
+		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.StartGroupType)
+		buf = ptr.Interface().(Marshaler).AppendSerializer(buf)
+		return protowire.AppendTag(buf, protowire.Number(tag), protowire.EndGroupType), true, nil
+	}
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.BytesType)
+	// the content is written after buf, then moved to leave room for the length
+	start := len(buf)
+	temp, err := t.enc(buf, v)
+	if err != nil {
+		return buf, true, debugs.WarpError(err, fmt.Sprintf("encode %s error", t.typ))
+	}
+	contentLen := len(temp) - start
+	sizeLen := protowire.SizeVarint(uint64(contentLen))
+	temp = append(temp, make([]byte, sizeLen)...)
+	copy(temp[start+sizeLen:], temp[start:start+contentLen])
+	protowire.AppendVarint(temp[:start], uint64(contentLen))
+	return temp, true, nil
+}
+
+// decodeCustom decode a value of registered type after the tag
+func (d *decoder) decodeCustom(buf []byte, t *customType, tag protowire.Number, typ protowire.Type) ([]byte, interface{}, error) {
+	if t.enc == nil {
+		if typ != protowire.StartGroupType {
+			return buf, nil, fmt.Errorf("[%s]%s not a group, type=%d", debugs.SourceCodeLoc(1), t.typ, typ)
+		}
+		ptr := reflect.New(t.typ)
+		leftData, err := ptr.Interface().(Unmarshaler).ConsumeSerializer(buf)
+		if err != nil {
+			return buf, nil, debugs.WarpError(err, fmt.Sprintf("ConsumeSerializer of %s error", t.typ))
+		}
+		num, endType, n := protowire.ConsumeTag(leftData)
+		if n < 0 || endType != protowire.EndGroupType || num != tag {
+			return buf, nil, fmt.Errorf("[%s]%s end tag error,code=%d", debugs.SourceCodeLoc(1), t.typ, n)
+		}
+		return leftData[n:], ptr.Elem().Interface(), nil
+	}
+	if typ != protowire.BytesType {
+		return buf, nil, fmt.Errorf("[%s]%s not a BytesType, type=%d", debugs.SourceCodeLoc(1), t.typ, typ)
+	}
+	data, n := protowire.ConsumeBytes(buf)
+	if n < 0 {
+		return buf, nil, fmt.Errorf("[%s]read BytesType error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	if err := d.checkString(len(data)); err != nil {
+		return buf, nil, err
+	}
+	value, err := t.dec(data)
+	if err != nil {
+		return buf, nil, debugs.WarpError(err, fmt.Sprintf("decode %s error", t.typ))
+	}
+	return buf[n:], value, nil
+}
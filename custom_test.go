@@ -0,0 +1,162 @@
+package serializer
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type testMoney int64
+
+type testPoint struct {
+	X, Y int32
+}
+
+func (p *testPoint) AppendSerializer(buf []byte) []byte {
+	buf, _ = Encode(buf, 1, p.X)
+	buf, _ = Encode(buf, 2, p.Y)
+	return buf
+}
+
+func (p *testPoint) ConsumeSerializer(buf []byte) ([]byte, error) {
+	for len(buf) > 0 {
+		_, num, typ, _ := consumeHead(buf)
+		if typ == protowire.EndGroupType {
+			return buf, nil
+		}
+		leftData, value, err := decode(buf, 0)
+		if err != nil {
+			return buf, err
+		}
+		v, ok := value.(int32)
+		if !ok {
+			return buf, errors.New("not a int32")
+		}
+		switch num {
+		case 1:
+			p.X = v
+		case 2:
+			p.Y = v
+		}
+		buf = leftData
+	}
+	return buf, nil
+}
+
+func init() {
+	err := RegisterType(MinUserTypeID, testMoney(0), func(buf []byte, v interface{}) ([]byte, error) {
+		return protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(v.(testMoney)))), nil
+	}, func(data []byte) (interface{}, error) {
+		v, n := protowire.ConsumeVarint(data)
+		if n != len(data) {
+			return nil, errors.New("bad money")
+		}
+		return testMoney(protowire.DecodeZigZag(v)), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	if err = RegisterType(MinUserTypeID+1, testPoint{}, nil, nil); err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterType(t *testing.T) {
+	data := []interface{}{testMoney(-12345), testPoint{X: 1, Y: -2}, "abc"}
+	buf, err := Encode(nil, 1, data)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	for _, version := range []int{FormatV1, FormatV2} {
+		encoded, _ := EncodeVersion(nil, 1, data, version)
+		_, value, err := Decode(encoded)
+		if err != nil || !reflect.DeepEqual(data, value) {
+			t.Errorf("version %d not equal, value=%+v, err=%+v", version, value, err)
+		}
+		if err = Validate(encoded, ValidateOptions{}); err != nil {
+			t.Errorf("version %d should be valid, err=%+v", version, err)
+		}
+	}
+	type wrapper struct {
+		Price testMoney
+		Pos   testPoint
+	}
+	w := wrapper{Price: 99, Pos: testPoint{X: 3, Y: 4}}
+	buf, _ = Encode(nil, 1, w)
+	var out wrapper
+	if _, err = DecodeInto(buf, &out); err != nil || !reflect.DeepEqual(w, out) {
+		t.Errorf("DecodeInto not equal, out=%+v, err=%+v", out, err)
+	}
+	_, value, _ := Decode(buf)
+	if items := value.([]interface{}); items[0] != testMoney(99) {
+		t.Errorf("field not decoded as registered type, %+v", items)
+	}
+
+	// a pointer to registered type is encoded as the value
+	m, p := testMoney(5), testPoint{X: 1, Y: 2}
+	buf, _ = Encode(nil, 1, []interface{}{m, &m, p, &p})
+	if _, value, err = Decode(buf); err != nil || !reflect.DeepEqual([]interface{}{m, m, p, p}, value) {
+		t.Errorf("pointer not decoded as registered type, value=%+v, err=%+v", value, err)
+	}
+	type ptrWrapper struct {
+		Price *testMoney
+		Pos   *testPoint
+		Nil   *testMoney
+	}
+	pw := ptrWrapper{Price: &m, Pos: &p}
+	for _, version := range []int{FormatV1, FormatV2} {
+		buf, _ = EncodeVersion(nil, 1, pw, version)
+		var pwOut ptrWrapper
+		if _, err = DecodeInto(buf, &pwOut); err != nil || !reflect.DeepEqual(pw, pwOut) {
+			t.Errorf("version %d DecodeInto not equal, out=%+v, err=%+v", version, pwOut, err)
+		}
+	}
+
+	cases := []struct {
+		id     uint64
+		sample interface{}
+	}{
+		{tString, testMoney(0)},           // reserved
+		{MinUserTypeID, struct{}{}},       // not defined type
+		{MinUserTypeID, int64(0)},         // predeclared type
+		{MinUserTypeID + 2, testMoney(0)}, // type registered
+		{MinUserTypeID, time0{}},          // id registered
+	}
+	enc := func(buf []byte, v interface{}) ([]byte, error) { return buf, nil }
+	dec := func(data []byte) (interface{}, error) { return nil, nil }
+	for idx, c := range cases {
+		if err = RegisterType(c.id, c.sample, enc, dec); err == nil {
+			t.Errorf("case %d should fail", idx)
+		}
+	}
+	if err = RegisterType(MinUserTypeID+3, time0{}, nil, nil); err == nil {
+		t.Errorf("type not implement Marshaler should fail")
+	}
+}
+
+type time0 struct{}
+
+// testPlainPoint is a Marshaler without registration
+type testPlainPoint struct {
+	testPoint
+}
+
+// a Marshaler without registration is encoded as the struct
+func TestMarshaler(t *testing.T) {
+	type plain struct {
+		X, Y int32
+	}
+	expect, _ := Encode(nil, 1, plain{X: 5, Y: 6})
+	buf, err := Encode(nil, 1, &testPlainPoint{testPoint{X: 5, Y: 6}})
+	if err != nil || !bytes.Equal(expect, buf) {
+		t.Errorf("not equal, err=%+v\n%x\n%x", err, expect, buf)
+	}
+	var p testPlainPoint
+	if _, err = DecodeInto(expect, &p); err != nil || p.X != 5 || p.Y != 6 {
+		t.Errorf("DecodeInto error, p=%+v, err=%+v", p, err)
+	}
+}
@@ -0,0 +1,174 @@
+package serializer
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// the errors returned when a limit of DecodeOptions is hit
+var (
+	ErrMaxDepth      = errors.New("max depth exceeded")
+	ErrMaxElements   = errors.New("max elements exceeded")
+	ErrMaxStringLen  = errors.New("max string length exceeded")
+	ErrMaxTotalAlloc = errors.New("max total alloc exceeded")
+)
+
+// sizeOfInterface is the size of an interface{} value, to estimate the memory of arrays and maps
+const sizeOfInterface = 16
+
+// DecodeOptions limits of decoding, for the buffers from untrusted input. zero value means no limit.
+type DecodeOptions struct {
+	MaxDepth      int // max nesting level of arrays and maps
+	MaxElements   int // max count of array items, map entries and packed slice values
+	MaxStringLen  int // max length of a string, []byte or json value
+	MaxTotalAlloc int // max bytes allocated by the values, it is estimated
+
+	KeepUnknownTypes bool // the lenient mode, values of unknown data types are decoded to RawValue
+	RawMessages      bool // the nested arrays and maps are returned as RawMessage without decoding
+	TypedNil         bool // null of a nil pointer is decoded as typed nil pointer like (*int)(nil), not untyped nil
+}
+
+// DecodeWithOptions decode like Decode, an error wrapping ErrMaxDepth, ErrMaxElements, ErrMaxStringLen or
+// ErrMaxTotalAlloc is returned if a limit is hit. a compression frame is checked by its decompressed length.
+func DecodeWithOptions(buf []byte, opts DecodeOptions) ([]byte, interface{}, error) {
+	d := decoder{opts: opts}
+	return d.decodeBuffer(buf)
+}
+
+// decoder keep the limits and the usage of one decoding
+type decoder struct {
+	opts     DecodeOptions
+	depth    int
+	elements uint64
+	alloc    uint64
+}
+
+// enter a group
+func (d *decoder) enter() error {
+	d.depth++
+	if d.opts.MaxDepth > 0 && d.depth > d.opts.MaxDepth {
+		return debugs.WarpError(ErrMaxDepth, fmt.Sprintf("depth is more than %d", d.opts.MaxDepth))
+	}
+	return nil
+}
+
+// leave a group
+func (d *decoder) leave() {
+	d.depth--
+}
+
+// addElements count the elements and the bytes allocated for them
+func (d *decoder) addElements(elements uint64, size uint64) error {
+	d.elements = saturatingAdd(d.elements, elements)
+	if d.opts.MaxElements > 0 && d.elements > uint64(d.opts.MaxElements) {
+		return debugs.WarpError(ErrMaxElements, fmt.Sprintf("elements is more than %d", d.opts.MaxElements))
+	}
+	return d.addAlloc(size)
+}
+
+// addAlloc count the bytes allocated
+func (d *decoder) addAlloc(size uint64) error {
+	d.alloc = saturatingAdd(d.alloc, size)
+	if d.opts.MaxTotalAlloc > 0 && d.alloc > uint64(d.opts.MaxTotalAlloc) {
+		return debugs.WarpError(ErrMaxTotalAlloc, fmt.Sprintf("alloc is more than %d bytes", d.opts.MaxTotalAlloc))
+	}
+	return nil
+}
+
+// checkString check the length of a string, []byte or json value
+func (d *decoder) checkString(size int) error {
+	if d.opts.MaxStringLen > 0 && size > d.opts.MaxStringLen {
+		return debugs.WarpError(ErrMaxStringLen, fmt.Sprintf("length %d is more than %d", size, d.opts.MaxStringLen))
+	}
+	return d.addAlloc(uint64(size))
+}
+
+// checkPacked count the values of a packed slice before decoding it
+func (d *decoder) checkPacked(data []byte, golangType uint64) error {
+	var count, size uint64
+	switch golangType {
+	case tFloat32Slice:
+		count, size = uint64(len(data)/4), 4
+	case tFloat64Slice:
+		count, size = uint64(len(data)/8), 8
+	case tInt8Slice, tBoolSlice:
+		count, size = uint64(packedVarintCount(data)), 1
+	case tInt16Slice, tUint16Slice:
+		count, size = uint64(packedVarintCount(data)), 2
+	case tInt32Slice, tUint32Slice:
+		count, size = uint64(packedVarintCount(data)), 4
+	default:
+		count, size = uint64(packedVarintCount(data)), 8
+	}
+	return d.addElements(count, count*size)
+}
+
+// decompressFrame check the decompressed length before decompressing,
+// decompressFrame fails as soon as the data is more than the length, so the length counted is the real allocation
+func (d *decoder) decompressFrame(data []byte) ([]byte, error) {
+	_, n := protowire.ConsumeVarint(data)
+	if n < 0 {
+		return nil, fmt.Errorf("[%s]read codec error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	rawLen, m := protowire.ConsumeVarint(data[n:])
+	if m < 0 {
+		return nil, fmt.Errorf("[%s]read raw length error,code=%d", debugs.SourceCodeLoc(1), m)
+	}
+	if err := d.addAlloc(rawLen); err != nil {
+		return nil, err
+	}
+	return decompressFrame(data)
+}
+
+// checkDepth check the nesting level of a value before converting it to V1, without recursion
+func (d *decoder) checkDepth(buf []byte, version int) error {
+	if d.opts.MaxDepth <= 0 {
+		return nil
+	}
+	depth := d.depth
+	for len(buf) > 0 {
+		_, _, typ, n := readHead(buf, version)
+		if n < 0 {
+			return fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		buf = buf[n:]
+		switch typ {
+		case protowire.StartGroupType:
+			depth++
+			if depth > d.opts.MaxDepth {
+				return debugs.WarpError(ErrMaxDepth, fmt.Sprintf("depth is more than %d", d.opts.MaxDepth))
+			}
+			continue
+		case protowire.EndGroupType:
+			depth--
+		default:
+			valueLen := protowire.ConsumeFieldValue(1, typ, buf)
+			if valueLen < 0 {
+				return fmt.Errorf("[%s]read field value error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+			}
+			buf = buf[valueLen:]
+		}
+		if depth == d.depth {
+			return nil // end of the value
+		}
+	}
+	return nil
+}
+
+func saturatingMul(x uint64, y uint64) uint64 {
+	if x != 0 && (x*y)/x != y {
+		return ^uint64(0)
+	}
+	return x * y
+}
+
+func saturatingAdd(x uint64, y uint64) uint64 {
+	if x+y < x {
+		return ^uint64(0)
+	}
+	return x + y
+}
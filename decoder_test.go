@@ -0,0 +1,120 @@
+package serializer
+
+import (
+	"compress/flate"
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestDecodeWithOptions(t *testing.T) {
+	data := getTestData()
+	buf, _ := Encode(nil, 1, data)
+	_, value, err := DecodeWithOptions(buf, DecodeOptions{MaxDepth: 3, MaxElements: 100, MaxStringLen: 4, MaxTotalAlloc: 4096})
+	if err != nil || !reflect.DeepEqual(data, value) {
+		t.Errorf("decode error, value=%+v, err=%+v", value, err)
+	}
+	deep := []interface{}{[]interface{}{[]interface{}{[]interface{}{1}}}}
+	deepV1, _ := Encode(nil, 1, deep)
+	deepV2, _ := EncodeVersion(nil, 1, deep, FormatV2)
+	longString, _ := Encode(nil, 1, []interface{}{strings.Repeat("a", 100)})
+	packed, _ := Encode(nil, 1, []int64{1, 2, 3, 4, 5})
+	compressed, _ := CompressArray(nil, longString, flate.BestSpeed)
+	hugeColumns := setType(nil, tColumns)
+	hugeColumns = protowire.AppendTag(hugeColumns, 1, protowire.StartGroupType)
+	hugeColumns = protowire.AppendTag(hugeColumns, tagOfRowCount, protowire.VarintType)
+	hugeColumns = protowire.AppendVarint(hugeColumns, 1<<62)
+	hugeColumns = protowire.AppendTag(hugeColumns, 1, protowire.EndGroupType)
+	cases := []struct {
+		buf    []byte
+		opts   DecodeOptions
+		expect error
+	}{
+		{deepV1, DecodeOptions{MaxDepth: 3}, ErrMaxDepth},
+		{deepV2, DecodeOptions{MaxDepth: 3}, ErrMaxDepth},
+		{buf, DecodeOptions{MaxElements: 10}, ErrMaxElements},
+		{packed, DecodeOptions{MaxElements: 4}, ErrMaxElements},
+		{hugeColumns, DecodeOptions{MaxTotalAlloc: 1 << 20}, ErrMaxTotalAlloc},
+		{longString, DecodeOptions{MaxStringLen: 99}, ErrMaxStringLen},
+		{longString, DecodeOptions{MaxTotalAlloc: 99}, ErrMaxTotalAlloc},
+		{compressed, DecodeOptions{MaxTotalAlloc: 100}, ErrMaxTotalAlloc},
+	}
+	for idx, c := range cases {
+		if _, _, err = DecodeWithOptions(c.buf, c.opts); !errors.Is(err, c.expect) {
+			t.Errorf("case %d should be %v, err=%+v", idx, c.expect, err)
+		}
+	}
+	if _, _, err = DecodeWithOptions(deepV2, DecodeOptions{MaxDepth: 4}); err != nil {
+		t.Errorf("decode error, err=%+v", err)
+	}
+}
+
+func TestReadEachRowWithOptions(t *testing.T) {
+	rows := getTestRows()
+	buf, _ := Encode(nil, 1, rows)
+	// the limits apply to each row
+	count := 0
+	err := ReadEachRowWithOptions(buf, DecodeOptions{MaxElements: len(rows[0])}, func(tag int, cols ...interface{}) error {
+		count++
+		return nil
+	})
+	if err != nil || count != len(rows) {
+		t.Errorf("ReadEachRowWithOptions error, count=%d, err=%+v", count, err)
+	}
+	err = ReadEachRowWithOptions(buf, DecodeOptions{MaxStringLen: 3}, func(tag int, cols ...interface{}) error {
+		return nil
+	})
+	if !errors.Is(err, ErrMaxStringLen) {
+		t.Errorf("should be ErrMaxStringLen, err=%+v", err)
+	}
+}
+
+// the compression frame is checked with MaxTotalAlloc, whatever its raw length declares
+func TestDecodeWithOptionsCompressed(t *testing.T) {
+	compressed, err := (&flateCompressor{}).Compress(nil, make([]byte, 50*1024*1024), flate.BestSpeed)
+	if err != nil {
+		t.Errorf("compress error, err=%+v", err)
+		return
+	}
+	frame := protowire.AppendVarint(nil, CompressorFlate)
+	frame = protowire.AppendVarint(frame, 10)
+	frame = append(frame, compressed...)
+	bomb := setType(nil, tCompressed)
+	bomb = protowire.AppendTag(bomb, 1, protowire.BytesType)
+	bomb = protowire.AppendBytes(bomb, frame)
+	opts := DecodeOptions{MaxTotalAlloc: 1 << 20}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, _, err = DecodeWithOptions(bomb, opts); err == nil {
+		t.Errorf("should fail for more data than the raw length")
+	}
+	err = ReadEachRowWithOptions(bomb, opts, func(tag int, cols ...interface{}) error { return nil })
+	if err == nil {
+		t.Errorf("should fail for more data than the raw length")
+	}
+	runtime.ReadMemStats(&after)
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 2*1024*1024 {
+		t.Errorf("allocated %d bytes with MaxTotalAlloc 1MB", alloc)
+	}
+	// a frame declares its real length is rejected before decompressing
+	rows := make([]interface{}, 0, 2000)
+	for i := 0; i < 2000; i++ {
+		rows = append(rows, []interface{}{strings.Repeat("x", 1024)})
+	}
+	src, _ := Encode(nil, 1, rows)
+	buf, _ := CompressArray(nil, src, flate.BestSpeed)
+	if _, _, err = DecodeWithOptions(buf, opts); !errors.Is(err, ErrMaxTotalAlloc) {
+		t.Errorf("should be ErrMaxTotalAlloc, err=%+v", err)
+	}
+	err = ReadEachRowWithOptions(buf, opts, func(tag int, cols ...interface{}) error { return nil })
+	if !errors.Is(err, ErrMaxTotalAlloc) {
+		t.Errorf("should be ErrMaxTotalAlloc, err=%+v", err)
+	}
+	if err = ReadEachRowWithOptions(buf, DecodeOptions{}, func(tag int, cols ...interface{}) error { return nil }); err != nil {
+		t.Errorf("read error, err=%+v", err)
+	}
+}
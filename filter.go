@@ -0,0 +1,412 @@
+package serializer
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// Filter is a predicate over the columns of a row, it is checked against the raw values in the buffer,
+// so the rows not match are never decoded. see Eq, Lt, Gt, In, And, Or, Not.
+//
+// numbers of different types are compared by value, string and []byte are compared by bytes.
+// a column missing in the row, or a value not comparable with the operand, does not match.
+type Filter interface {
+	// match check the raw cells of a row, cells[i] is the column i with its data type field
+	match(cells [][]byte) (bool, error)
+	// maxColumn return the largest column index used by the filter, -1 if no column is used
+	maxColumn() int
+}
+
+const (
+	opEq = iota
+	opLt
+	opGt
+)
+
+// Eq match rows which column col equals value
+func Eq(col int, value interface{}) Filter {
+	return newCompareFilter(col, opEq, value)
+}
+
+// Lt match rows which column col is less than value
+func Lt(col int, value interface{}) Filter {
+	return newCompareFilter(col, opLt, value)
+}
+
+// Gt match rows which column col is greater than value
+func Gt(col int, value interface{}) Filter {
+	return newCompareFilter(col, opGt, value)
+}
+
+// In match rows which column col equals one of values
+func In(col int, values ...interface{}) Filter {
+	f := &inFilter{col: col, values: make([]scalar, 0, len(values))}
+	for _, value := range values {
+		s, err := scalarOf(value)
+		if err != nil {
+			f.err = err
+			break
+		}
+		f.values = append(f.values, s)
+	}
+	return f
+}
+
+// And match rows which match all the filters
+func And(filters ...Filter) Filter {
+	return andFilter(filters)
+}
+
+// Or match rows which match any of the filters
+func Or(filters ...Filter) Filter {
+	return orFilter(filters)
+}
+
+// Not match rows which not match f
+func Not(f Filter) Filter {
+	return notFilter{f: f}
+}
+
+// ReadEachRowFiltered read rows like ReadEachRow, rows not match filter are skipped without decoding.
+// only the columns at cols are decoded like ReadEachRowProjected, nil cols means all the columns.
+func ReadEachRowFiltered(buf []byte, filter Filter, cols []int, callback RowCallback) error {
+	it := NewRowIterator(buf)
+	it.filter = filter
+	it.proj = cols
+	for it.Next() {
+		tag, values := it.Row()
+		if err := callback(tag, values...); err != nil {
+			return debugs.WarpError(err, "callback error")
+		}
+	}
+	return it.Err()
+}
+
+// match split the cells of the array row which are used by filter, and check the row
+func (it *RowIterator) match(row []byte) (bool, error) {
+	_, _, typ, n := consumeHead(row)
+	if n < 0 || typ != protowire.StartGroupType {
+		return false, fmt.Errorf("[%s]row not a array, type=%d, code=%d", debugs.SourceCodeLoc(1), typ, n)
+	}
+	maxCol := it.filter.maxColumn()
+	it.cells = it.cells[:0]
+	for buf := row[n:]; len(it.cells) <= maxCol; {
+		golangType, _, typ, n := consumeHead(buf)
+		if n < 0 {
+			return false, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		if typ == protowire.EndGroupType {
+			break
+		}
+		leftData, err := skipValue(buf)
+		if err != nil {
+			return false, debugs.WarpError(err, "skipValue error")
+		}
+		if golangType != tChecksum && golangType != tIndex {
+			it.cells = append(it.cells, buf[:len(buf)-len(leftData)])
+		}
+		buf = leftData
+	}
+	return it.filter.match(it.cells)
+}
+
+type compareFilter struct {
+	col   int
+	op    int
+	value scalar
+	err   error
+}
+
+func newCompareFilter(col int, op int, value interface{}) *compareFilter {
+	s, err := scalarOf(value)
+	return &compareFilter{col: col, op: op, value: s, err: err}
+}
+
+func (f *compareFilter) match(cells [][]byte) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	if f.col < 0 || f.col >= len(cells) {
+		return false, nil
+	}
+	s, ok := readScalar(cells[f.col])
+	if !ok {
+		return false, nil
+	}
+	c, ok := compareScalar(s, f.value)
+	if !ok {
+		return false, nil
+	}
+	switch f.op {
+	case opLt:
+		return c < 0, nil
+	case opGt:
+		return c > 0, nil
+	default:
+		return c == 0, nil
+	}
+}
+
+func (f *compareFilter) maxColumn() int {
+	return f.col
+}
+
+type inFilter struct {
+	col    int
+	values []scalar
+	err    error
+}
+
+func (f *inFilter) match(cells [][]byte) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	if f.col < 0 || f.col >= len(cells) {
+		return false, nil
+	}
+	s, ok := readScalar(cells[f.col])
+	if !ok {
+		return false, nil
+	}
+	for _, value := range f.values {
+		if c, ok := compareScalar(s, value); ok && c == 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *inFilter) maxColumn() int {
+	return f.col
+}
+
+type andFilter []Filter
+
+func (f andFilter) match(cells [][]byte) (bool, error) {
+	for _, sub := range f {
+		if ok, err := sub.match(cells); !ok || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (f andFilter) maxColumn() int {
+	return maxColumnOf(f)
+}
+
+type orFilter []Filter
+
+func (f orFilter) match(cells [][]byte) (bool, error) {
+	for _, sub := range f {
+		if ok, err := sub.match(cells); ok || err != nil {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func (f orFilter) maxColumn() int {
+	return maxColumnOf(f)
+}
+
+type notFilter struct {
+	f Filter
+}
+
+func (f notFilter) match(cells [][]byte) (bool, error) {
+	ok, err := f.f.match(cells)
+	return !ok && err == nil, err
+}
+
+func (f notFilter) maxColumn() int {
+	return f.f.maxColumn()
+}
+
+func maxColumnOf(filters []Filter) int {
+	maxCol := -1
+	for _, f := range filters {
+		if col := f.maxColumn(); col > maxCol {
+			maxCol = col
+		}
+	}
+	return maxCol
+}
+
+const (
+	kindInt = iota + 1
+	kindUint
+	kindFloat
+	kindBool
+	kindBytes
+)
+
+// scalar is a basic value for comparing, only the field of kind is used
+type scalar struct {
+	kind int
+	i    int64
+	u    uint64
+	f    float64
+	b    []byte
+}
+
+// scalarOf convert the operand of filter to scalar
+func scalarOf(v interface{}) (scalar, error) {
+	switch v1 := v.(type) {
+	case bool:
+		return scalar{kind: kindBool, u: protowire.EncodeBool(v1)}, nil
+	case int8:
+		return scalar{kind: kindInt, i: int64(v1)}, nil
+	case int16:
+		return scalar{kind: kindInt, i: int64(v1)}, nil
+	case int32:
+		return scalar{kind: kindInt, i: int64(v1)}, nil
+	case int64:
+		return scalar{kind: kindInt, i: v1}, nil
+	case int:
+		return scalar{kind: kindInt, i: int64(v1)}, nil
+	case time.Duration:
+		return scalar{kind: kindInt, i: int64(v1)}, nil
+	case uint8:
+		return scalar{kind: kindUint, u: uint64(v1)}, nil
+	case uint16:
+		return scalar{kind: kindUint, u: uint64(v1)}, nil
+	case uint32:
+		return scalar{kind: kindUint, u: uint64(v1)}, nil
+	case uint64:
+		return scalar{kind: kindUint, u: v1}, nil
+	case float32:
+		return scalar{kind: kindFloat, f: float64(v1)}, nil
+	case float64:
+		return scalar{kind: kindFloat, f: v1}, nil
+	case string:
+		return scalar{kind: kindBytes, b: []byte(v1)}, nil
+	case []byte:
+		return scalar{kind: kindBytes, b: v1}, nil
+	default:
+		return scalar{}, fmt.Errorf("[%s]not support filter value type %T", debugs.SourceCodeLoc(1), v)
+	}
+}
+
+// readScalar read a raw cell as scalar without allocation, ok is false if the cell is not a basic type
+func readScalar(cell []byte) (s scalar, ok bool) {
+	golangType, _, typ, n := consumeHead(cell)
+	if n < 0 {
+		return s, false
+	}
+	cell = cell[n:]
+	var v uint64
+	switch typ {
+	case protowire.VarintType:
+		v, n = protowire.ConsumeVarint(cell)
+	case protowire.Fixed32Type:
+		var v32 uint32
+		v32, n = protowire.ConsumeFixed32(cell)
+		v = uint64(v32)
+	case protowire.Fixed64Type:
+		v, n = protowire.ConsumeFixed64(cell)
+	case protowire.BytesType:
+		s.b, n = protowire.ConsumeBytes(cell)
+	default:
+		return s, false
+	}
+	if n < 0 {
+		return s, false
+	}
+	switch golangType {
+	case tBool:
+		s.kind, s.u = kindBool, v
+	case tInt8, tInt16, tInt32, tInt64, tInt:
+		s.kind, s.i = kindInt, int64(v)
+	case tSint8, tSint16, tSint32, tSint64, tSint, tDuration:
+		s.kind, s.i = kindInt, protowire.DecodeZigZag(v)
+	case tUint8, tUint16, tUint32, tUint64:
+		s.kind, s.u = kindUint, v
+	case tFloat32:
+		s.kind, s.f = kindFloat, float64(math.Float32frombits(uint32(v)))
+	case tFloat64:
+		s.kind, s.f = kindFloat, math.Float64frombits(v)
+	case tString, tBytes:
+		s.kind = kindBytes
+	default:
+		return s, false
+	}
+	if (typ == protowire.BytesType) != (s.kind == kindBytes) {
+		return s, false // the data type not match the wire type
+	}
+	return s, true
+}
+
+// compareScalar return -1, 0, 1 like bytes.Compare, ok is false if a and b are not comparable
+func compareScalar(a scalar, b scalar) (c int, ok bool) {
+	switch {
+	case a.kind == kindBytes || b.kind == kindBytes:
+		if a.kind != b.kind {
+			return 0, false
+		}
+		return bytes.Compare(a.b, b.b), true
+	case a.kind == kindBool || b.kind == kindBool:
+		if a.kind != b.kind {
+			return 0, false
+		}
+		return compareUint(a.u, b.u), true
+	case a.kind == kindFloat || b.kind == kindFloat:
+		x, y := a.float(), b.float()
+		if math.IsNaN(x) || math.IsNaN(y) {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	case a.kind == kindInt && b.kind == kindInt:
+		switch {
+		case a.i < b.i:
+			return -1, true
+		case a.i > b.i:
+			return 1, true
+		}
+		return 0, true
+	case a.kind == kindInt: // b is uint
+		if a.i < 0 {
+			return -1, true
+		}
+		return compareUint(uint64(a.i), b.u), true
+	case b.kind == kindInt: // a is uint
+		if b.i < 0 {
+			return 1, true
+		}
+		return compareUint(a.u, uint64(b.i)), true
+	default:
+		return compareUint(a.u, b.u), true
+	}
+}
+
+func compareUint(x uint64, y uint64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func (s scalar) float() float64 {
+	switch s.kind {
+	case kindInt:
+		return float64(s.i)
+	case kindUint:
+		return float64(s.u)
+	}
+	return s.f
+}
@@ -0,0 +1,66 @@
+package serializer
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestReadEachRowFiltered(t *testing.T) {
+	rows := make([][]interface{}, 100)
+	for idx := range rows {
+		status := "ok"
+		if idx%3 == 0 {
+			status = "fail"
+		}
+		rows[idx] = []interface{}{idx, uint32(idx * 2), float32(idx) / 2, status, map[string]interface{}{"a": idx}}
+	}
+	buf, _ := EncodeWithChecksum(nil, 1, rows)
+	cases := []struct {
+		filter Filter
+		expect func(idx int) bool
+	}{
+		{Gt(0, 90), func(idx int) bool { return idx > 90 }},
+		{Lt(1, int8(10)), func(idx int) bool { return idx < 5 }},
+		{Eq(2, 10), func(idx int) bool { return idx == 20 }},
+		{And(Gt(1, 100), Eq(3, "ok")), func(idx int) bool { return idx > 50 && idx%3 != 0 }},
+		{Or(In(0, 1, 2, uint64(3)), Eq(3, []byte("fail"))), func(idx int) bool { return idx <= 3 || idx%3 == 0 }},
+		{Not(Lt(0, 95.5)), func(idx int) bool { return idx >= 96 }},
+		{Eq(3, 1), func(idx int) bool { return false }},      // not comparable
+		{Eq(4, "a"), func(idx int) bool { return false }},    // map is not a basic type
+		{Not(Eq(10, 1)), func(idx int) bool { return true }}, // missing column
+		{Gt(0, uint64(math.MaxUint64)), func(idx int) bool { return false }},
+	}
+	for caseIdx, c := range cases {
+		var expect [][]interface{}
+		for idx, row := range rows {
+			if c.expect(idx) {
+				expect = append(expect, []interface{}{row[0], row[3]})
+			}
+		}
+		var got [][]interface{}
+		err := ReadEachRowFiltered(buf, c.filter, []int{0, 3}, func(tag int, cols ...interface{}) error {
+			got = append(got, cols)
+			return nil
+		})
+		if err != nil || !reflect.DeepEqual(expect, got) {
+			t.Errorf("case %d not equal, got=%+v, err=%+v", caseIdx, got, err)
+		}
+	}
+	count := 0
+	err := ReadEachRowFiltered(buf, Eq(0, 5), nil, func(tag int, cols ...interface{}) error {
+		count++
+		if tag != 6 || !reflect.DeepEqual(rows[5], cols) {
+			t.Errorf("row not equal, tag=%d, cols=%+v", tag, cols)
+		}
+		return nil
+	})
+	if err != nil || count != 1 {
+		t.Errorf("ReadEachRowFiltered error, count=%d, err=%+v", count, err)
+	}
+	if err = ReadEachRowFiltered(buf, Eq(0, struct{}{}), nil, func(tag int, cols ...interface{}) error {
+		return nil
+	}); err == nil {
+		t.Errorf("not supported filter value should fail")
+	}
+}
@@ -0,0 +1,121 @@
+package serializer
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// ErrPathNotFound is returned by Get when an index of the path is out of range
+var ErrPathNotFound = errors.New("path not found")
+
+// Get decode only the value at path, each element of path is the index of an array item, from 0.
+// the siblings are skipped without decoding, for example Get(buf, 5000, 3) is column 3 of row 5000.
+func Get(buf []byte, path ...int) (interface{}, error) {
+	version, body, err := ParseHeader(buf)
+	if err != nil {
+		return nil, debugs.WarpError(err, "ParseHeader error")
+	}
+	start, end, left, err := lookup(body, version, path)
+	if err != nil {
+		return nil, err
+	}
+	value := body[start:end]
+	if version != FormatV1 {
+		if value, _, err = convertValue(nil, value, version, FormatV1); err != nil {
+			return nil, debugs.WarpError(err, "convert to V1 error")
+		}
+	}
+	if len(left) > 0 {
+		// the rest of path is in a compression frame
+		_, _, _, n := consumeHead(value)
+		frame, _ := protowire.ConsumeBytes(value[n:]) // checked by lookup
+		raw, err := decompressFrame(frame)
+		if err != nil {
+			return nil, debugs.WarpError(err, "decompressFrame error")
+		}
+		return Get(raw, left...)
+	}
+	_, out, err := decode(value, 0)
+	if err != nil {
+		return nil, debugs.WarpError(err, "decode error")
+	}
+	return out, nil
+}
+
+// GetRaw return the byte range of the value at path, buf[start:end] is the value with its data type field and tag,
+// in the format version of buf. the path can not go into a compression frame.
+func GetRaw(buf []byte, path ...int) (start int, end int, err error) {
+	version, body, err := ParseHeader(buf)
+	if err != nil {
+		return 0, 0, debugs.WarpError(err, "ParseHeader error")
+	}
+	start, end, left, err := lookup(body, version, path)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(left) > 0 {
+		return 0, 0, fmt.Errorf("[%s]can not get raw value in compression frame", debugs.SourceCodeLoc(1))
+	}
+	headerLen := len(buf) - len(body)
+	return headerLen + start, headerLen + end, nil
+}
+
+// lookup find the value at path in buf, return its range,
+// left is the rest of path if a compression frame is reached
+func lookup(buf []byte, version int, path []int) (start int, end int, left []int, err error) {
+	value := buf
+	for depth, idx := range path {
+		golangType, _, typ, n := readHead(value, version)
+		if n < 0 {
+			return 0, 0, nil, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		if golangType == tCompressed && typ == protowire.BytesType {
+			left = path[depth:]
+			break
+		}
+		if typ != protowire.StartGroupType || golangType == tMap || golangType == tAnyMap || golangType == tColumns {
+			return 0, 0, nil, fmt.Errorf("[%s]value at depth %d not a array, type=%d, data type=%d",
+				debugs.SourceCodeLoc(1), depth, typ, golangType)
+		}
+		if value, err = lookupItem(value[n:], version, idx); err != nil {
+			return 0, 0, nil, debugs.WarpError(err, fmt.Sprintf("lookup index %d at depth %d error", idx, depth))
+		}
+	}
+	valueLen := consumeValue(value, version)
+	if valueLen < 0 {
+		return 0, 0, nil, fmt.Errorf("[%s]read field value error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+	}
+	start = len(buf) - len(value)
+	return start, start + valueLen, left, nil
+}
+
+// lookupItem skip idx items of the array after the group start tag, return the data from the item
+func lookupItem(buf []byte, version int, idx int) ([]byte, error) {
+	if idx < 0 {
+		return buf, debugs.WarpError(ErrPathNotFound, fmt.Sprintf("negative index %d", idx))
+	}
+	for count := 0; ; {
+		golangType, _, typ, n := readHead(buf, version)
+		if n < 0 {
+			return buf, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		if typ == protowire.EndGroupType {
+			return buf, debugs.WarpError(ErrPathNotFound, fmt.Sprintf("array has %d items", count))
+		}
+		if golangType != tChecksum && golangType != tIndex {
+			if count == idx {
+				return buf, nil
+			}
+			count++
+		}
+		valueLen := consumeValue(buf, version)
+		if valueLen < 0 {
+			return buf, fmt.Errorf("[%s]read field value error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+		}
+		buf = buf[valueLen:]
+	}
+}
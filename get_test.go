@@ -0,0 +1,87 @@
+package serializer
+
+import (
+	"compress/flate"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	rows := getTestRows()
+	v1, _ := Encode(nil, 1, rows)
+	v2, _ := EncodeVersion(nil, 1, rows, FormatV2)
+	withChecksum, _ := EncodeWithChecksum(nil, 1, rows)
+	compressed, _ := CompressArray(nil, v1, flate.BestSpeed)
+	nested, _ := Encode(AppendArrayStart(nil, 1), 1, "head")
+	nested, _ = CompressArray(nested, v1, flate.BestSpeed)
+	nested = AppendArrayEnd(nested, 1)
+	for caseIdx, buf := range [][]byte{v1, v2, withChecksum, compressed} {
+		for idx, row := range rows {
+			for col, expect := range row {
+				value, err := Get(buf, idx, col)
+				if err != nil {
+					t.Errorf("case %d get error, err=%+v", caseIdx, err)
+					return
+				}
+				if !reflect.DeepEqual(expect, value) {
+					t.Errorf("case %d not equal, row=%d, col=%d, value=%+v", caseIdx, idx, col, value)
+				}
+			}
+		}
+		value, err := Get(buf, 1)
+		if err != nil || !reflect.DeepEqual(rows[1], value) {
+			t.Errorf("case %d get row error, value=%+v, err=%+v", caseIdx, value, err)
+		}
+		if _, err = Get(buf, len(rows)); !errors.Is(err, ErrPathNotFound) {
+			t.Errorf("case %d should be ErrPathNotFound, err=%+v", caseIdx, err)
+		}
+	}
+	value, err := Get(nested, 1, 2, 0)
+	if err != nil || !reflect.DeepEqual(rows[2][0], value) {
+		t.Errorf("get in compressed value error, value=%+v, err=%+v", value, err)
+	}
+	if _, err = Get(v1, 0, 0, 0); err == nil {
+		t.Errorf("basic type should not be indexed")
+	}
+}
+
+func TestGetRaw(t *testing.T) {
+	rows := getTestRows()
+	for _, version := range []int{FormatV1, FormatV2} {
+		buf, _ := EncodeVersion(nil, 1, rows, version)
+		start, end, err := GetRaw(buf, 2, 1)
+		if err != nil {
+			t.Errorf("GetRaw error, err=%+v", err)
+			return
+		}
+		value := buf[start:end]
+		if version == FormatV2 {
+			value, _, _ = convertValue(nil, value, FormatV2, FormatV1)
+		}
+		_, out, err := decode(value, 0)
+		if err != nil || !reflect.DeepEqual(rows[2][1], out) {
+			t.Errorf("version %d not equal, value=%+v, err=%+v", version, out, err)
+		}
+	}
+	buf, _ := Encode(nil, 1, rows)
+	start, end, err := GetRaw(buf)
+	if err != nil || start != 0 || end != len(buf) {
+		t.Errorf("GetRaw root error, start=%d, end=%d, err=%+v", start, end, err)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	rows := make([][]interface{}, 10000)
+	for idx := range rows {
+		rows[idx] = []interface{}{idx, "abc", 1.5}
+	}
+	buf, _ := Encode(nil, 1, rows)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Get(buf, 5000, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package serializer
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// Index is the byte offset of each row in buf, see BuildIndex
+type Index []int
+
+// indexTrailerSizeLen is the length of the fixed32 at the end of index trailer, which is the length of whole trailer
+const indexTrailerSizeLen = 4
+
+// BuildIndex record the byte offset of each row of the array in buf.
+// the index trailer added by AppendIndexTrailer is used if present, otherwise the rows are scanned without decoding.
+// the trailer is found from the end of buf without walking the items, and its bytes may be the end of another value,
+// so the index trailer is only trusted for buffers you wrote yourself.
+// buf can be V1 or V2, but not a compression frame.
+func BuildIndex(buf []byte) (Index, error) {
+	version, body, err := ParseHeader(buf)
+	if err != nil {
+		return nil, debugs.WarpError(err, "ParseHeader error")
+	}
+	headerLen := len(buf) - len(body)
+	golangType, tag, typ, headLen := readHead(body, version)
+	if headLen < 0 {
+		return nil, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), headLen)
+	}
+	if typ != protowire.StartGroupType || golangType != 0 {
+		return nil, fmt.Errorf("[%s]not a array, type=%d, data type=%d", debugs.SourceCodeLoc(1), typ, golangType)
+	}
+	if idx := readIndexTrailer(body, tag, version); idx != nil {
+		for i := range idx {
+			idx[i] += headerLen
+		}
+		return idx, nil
+	}
+	idx := make(Index, 0, defaultArrayCount)
+	for offset := headLen; ; {
+		golangType, _, typ, n := readHead(body[offset:], version)
+		if n < 0 {
+			return nil, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		if typ == protowire.EndGroupType {
+			return idx, nil
+		}
+		if golangType != tChecksum && golangType != tIndex {
+			idx = append(idx, headerLen+offset)
+		}
+		valueLen := consumeValue(body[offset:], version)
+		if valueLen < 0 {
+			return nil, fmt.Errorf("[%s]read field value error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+		}
+		offset += valueLen
+	}
+}
+
+// AppendIndexTrailer add the row offsets as the last item of the array, then BuildIndex read it without scanning.
+// buf must be one array in V1 or V2, with nothing after it, like the output of Encode or EncodeWithChecksum,
+// the index trailer is added after the CRC32C trailer, which is still checked by the readers.
+// the trailer is a data type field(35) and a bytes field with the tag of array,
+// the content is: count of rows varint, offset deltas varint, length of the trailer fixed32.
+func AppendIndexTrailer(buf []byte) ([]byte, error) {
+	version, body, err := ParseHeader(buf)
+	if err != nil {
+		return buf, debugs.WarpError(err, "ParseHeader error")
+	}
+	_, tag, _, _ := readHead(body, version)
+	if readIndexTrailer(body, tag, version) != nil {
+		return buf, fmt.Errorf("[%s]index trailer already exists", debugs.SourceCodeLoc(1))
+	}
+	idx, err := BuildIndex(buf)
+	if err != nil {
+		return buf, debugs.WarpError(err, "BuildIndex error")
+	}
+	if arrayLen := consumeValue(body, version); arrayLen != len(body) {
+		return buf, fmt.Errorf("[%s]buffer is not one array, array length=%d, buffer length=%d",
+			debugs.SourceCodeLoc(1), arrayLen, len(body))
+	}
+	headerLen := len(buf) - len(body)
+	content := protowire.AppendVarint(nil, uint64(len(idx)))
+	last := 0
+	for _, offset := range idx {
+		content = protowire.AppendVarint(content, uint64(offset-headerLen-last))
+		last = offset - headerLen
+	}
+	end, _ := appendHead(nil, 0, tag, protowire.EndGroupType, version)
+	buf = buf[:len(buf)-len(end)]
+	start := len(buf)
+	if buf, err = appendHead(buf, tIndex, tag, protowire.BytesType, version); err != nil {
+		return buf[:start], err
+	}
+	contentLen := len(content) + indexTrailerSizeLen
+	trailerLen := len(buf) - start + protowire.SizeVarint(uint64(contentLen)) + contentLen
+	buf = protowire.AppendVarint(buf, uint64(contentLen))
+	buf = append(buf, content...)
+	buf = protowire.AppendFixed32(buf, uint32(trailerLen))
+	return append(buf, end...), nil
+}
+
+// readIndexTrailer read the index trailer at the end of array, the offsets are relative to the array,
+// it returns nil if the trailer not exists
+func readIndexTrailer(body []byte, tag protowire.Number, version int) Index {
+	end, err := appendHead(nil, 0, tag, protowire.EndGroupType, version)
+	if err != nil || len(body) < len(end) {
+		return nil
+	}
+	endPos := len(body) - len(end)
+	trailer := indexTrailer(body[:endPos], tag, version)
+	if trailer == nil {
+		return nil
+	}
+	_, _, _, n := readHead(trailer, version)
+	content, _ := protowire.ConsumeBytes(trailer[n:])
+	content = content[:len(content)-indexTrailerSizeLen]
+	count, countLen := protowire.ConsumeVarint(content)
+	if countLen < 0 || count > uint64(len(content)) {
+		return nil
+	}
+	idx := make(Index, 0, count)
+	offset := 0
+	for content = content[countLen:]; len(content) > 0; {
+		delta, deltaLen := protowire.ConsumeVarint(content)
+		if deltaLen < 0 {
+			return nil
+		}
+		content = content[deltaLen:]
+		offset += int(delta)
+		if offset >= endPos {
+			return nil
+		}
+		idx = append(idx, offset)
+	}
+	if uint64(len(idx)) != count {
+		return nil
+	}
+	return idx
+}
+
+// indexTrailer return the index trailer at the end of data, which is the array body before the end flag,
+// it returns nil if the trailer not exists
+func indexTrailer(data []byte, tag protowire.Number, version int) []byte {
+	if len(data) < indexTrailerSizeLen {
+		return nil
+	}
+	trailerLen, _ := protowire.ConsumeFixed32(data[len(data)-indexTrailerSizeLen:])
+	if trailerLen == 0 || uint64(trailerLen) > uint64(len(data)) {
+		return nil
+	}
+	trailer := data[len(data)-int(trailerLen):]
+	golangType, num, typ, n := readHead(trailer, version)
+	if n < 0 || golangType != tIndex || num != tag || typ != protowire.BytesType {
+		return nil
+	}
+	content, contentLen := protowire.ConsumeBytes(trailer[n:])
+	if contentLen != len(trailer)-n || len(content) < indexTrailerSizeLen {
+		return nil
+	}
+	return trailer
+}
+
+// ReadRow decode the row n of the array, idx is built by BuildIndex from the same buf
+func ReadRow(buf []byte, idx Index, n int) (tag int, cols []interface{}, err error) {
+	if n < 0 || n >= len(idx) {
+		return 0, nil, debugs.WarpError(ErrPathNotFound, fmt.Sprintf("row %d not in index of %d rows", n, len(idx)))
+	}
+	version, body, err := ParseHeader(buf)
+	if err != nil {
+		return 0, nil, debugs.WarpError(err, "ParseHeader error")
+	}
+	offset := idx[n] - (len(buf) - len(body))
+	if offset < 0 || offset >= len(body) {
+		return 0, nil, fmt.Errorf("[%s]offset %d of row %d out of buffer", debugs.SourceCodeLoc(1), idx[n], n)
+	}
+	row := body[offset:]
+	valueLen := consumeValue(row, version)
+	if valueLen < 0 {
+		return 0, nil, fmt.Errorf("[%s]read row error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+	}
+	row = row[:valueLen]
+	if version != FormatV1 {
+		if row, _, err = convertValue(nil, row, version, FormatV1); err != nil {
+			return 0, nil, debugs.WarpError(err, "convert row to V1 error")
+		}
+	}
+	_, num, _, _ := consumeHead(row)
+	_, value, err := decode(row, 0)
+	if err != nil {
+		return 0, nil, debugs.WarpError(err, "decode row error")
+	}
+	if cols, ok := value.([]interface{}); ok {
+		return int(num), cols, nil
+	}
+	return int(num), []interface{}{value}, nil
+}
+
+// ScanRange read rows [from, to) of the index in workers goroutines, each goroutine read a continuous part of rows.
+// callback is called concurrently, the rows of one goroutine are in order.
+// the first error stops all the goroutines.
+func ScanRange(buf []byte, idx Index, from int, to int, workers int, callback RowCallback) error {
+	if from < 0 || to > len(idx) || from > to {
+		return debugs.WarpError(ErrPathNotFound, fmt.Sprintf("range [%d, %d) not in index of %d rows", from, to, len(idx)))
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > to-from {
+		workers = to - from
+	}
+	var (
+		wg      sync.WaitGroup
+		stopped int32
+		errs    = make([]error, workers)
+	)
+	for w := 0; w < workers; w++ {
+		start := from + (to-from)*w/workers
+		end := from + (to-from)*(w+1)/workers
+		wg.Add(1)
+		go func(w int, start int, end int) {
+			defer wg.Done()
+			for n := start; n < end && atomic.LoadInt32(&stopped) == 0; n++ {
+				tag, cols, err := ReadRow(buf, idx, n)
+				if err == nil {
+					err = callback(tag, cols...)
+				}
+				if err != nil {
+					errs[w] = debugs.WarpError(err, fmt.Sprintf("read row %d error", n))
+					atomic.StoreInt32(&stopped, 1)
+					return
+				}
+			}
+		}(w, start, end)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
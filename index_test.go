@@ -0,0 +1,134 @@
+package serializer
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestBuildIndex(t *testing.T) {
+	rows := getTestRows()
+	v1, _ := Encode(nil, 1, rows)
+	v2, _ := EncodeVersion(nil, 1, rows, FormatV2)
+	withChecksum, _ := EncodeWithChecksum(nil, 1, rows)
+	withHeader, _ := EncodeVersion(nil, 1, rows, FormatV1)
+	withChecksumV2, _, _ := convertValue(AppendHeader(nil, FormatV2), withChecksum, FormatV1, FormatV2)
+	for caseIdx, buf := range [][]byte{v1, v2, withChecksum, withHeader, withChecksumV2} {
+		idx, err := BuildIndex(buf)
+		if err != nil {
+			t.Errorf("case %d BuildIndex error, err=%+v", caseIdx, err)
+			return
+		}
+		withTrailer, err := AppendIndexTrailer(append([]byte{}, buf...))
+		if err != nil {
+			t.Errorf("case %d AppendIndexTrailer error, err=%+v", caseIdx, err)
+			return
+		}
+		idxFromTrailer, err := BuildIndex(withTrailer)
+		if err != nil || !reflect.DeepEqual(idx, idxFromTrailer) {
+			t.Errorf("case %d index not equal, %+v, %+v, err=%+v", caseIdx, idx, idxFromTrailer, err)
+		}
+		if _, err = AppendIndexTrailer(withTrailer); err == nil {
+			t.Errorf("case %d add trailer twice should fail", caseIdx)
+		}
+		// the trailer is skipped by all the readers
+		for _, data := range [][]byte{buf, withTrailer} {
+			for n := len(rows) - 1; n >= 0; n-- {
+				tag, cols, err := ReadRow(data, idx, n)
+				if err != nil || tag != n+1 || !reflect.DeepEqual(rows[n], cols) {
+					t.Errorf("case %d row %d not equal, tag=%d, cols=%+v, err=%+v", caseIdx, n, tag, cols, err)
+				}
+			}
+		}
+		_, value, err := Decode(withTrailer)
+		if err != nil || len(value.([]interface{})) != len(rows) {
+			t.Errorf("case %d Decode with trailer error, value=%+v, err=%+v", caseIdx, value, err)
+		}
+		count := 0
+		if err = ReadEachRow(withTrailer, func(tag int, cols ...interface{}) error {
+			count++
+			return nil
+		}); err != nil || count != len(rows) {
+			t.Errorf("case %d ReadEachRow with trailer error, count=%d, err=%+v", caseIdx, count, err)
+		}
+		if streamRows, err := readAllRows(bytes.NewReader(withTrailer)); err != nil || !reflect.DeepEqual(rows, streamRows) {
+			t.Errorf("case %d RowReader with trailer error, rows=%+v, err=%+v", caseIdx, streamRows, err)
+		}
+		if _, err = Get(withTrailer, len(rows)); !errors.Is(err, ErrPathNotFound) {
+			t.Errorf("case %d Get should skip the trailer, err=%+v", caseIdx, err)
+		}
+		if _, _, err = ReadRow(buf, idx, len(rows)); !errors.Is(err, ErrPathNotFound) {
+			t.Errorf("case %d should be ErrPathNotFound, err=%+v", caseIdx, err)
+		}
+	}
+}
+
+// a buffer with both the CRC32C trailer and the index trailer
+func TestIndexTrailerWithChecksum(t *testing.T) {
+	rows := []interface{}{[]interface{}{1, "hello"}, []interface{}{2, "world"}}
+	withChecksum, _ := EncodeWithChecksum(nil, 1, rows)
+	buf, err := AppendIndexTrailer(append([]byte{}, withChecksum...))
+	if err != nil {
+		t.Errorf("AppendIndexTrailer error, err=%+v", err)
+		return
+	}
+	if err = Validate(buf, ValidateOptions{}); err != nil {
+		t.Errorf("should be valid, err=%+v", err)
+	}
+	idx, err := BuildIndex(buf)
+	if expect, _ := BuildIndex(withChecksum); err != nil || !reflect.DeepEqual(expect, idx) {
+		t.Errorf("index not equal, %+v, %+v, err=%+v", expect, idx, err)
+	}
+	if tag, cols, err := ReadRow(buf, idx, 1); err != nil || tag != 2 || !reflect.DeepEqual(rows[1], cols) {
+		t.Errorf("ReadRow error, tag=%d, cols=%+v, err=%+v", tag, cols, err)
+	}
+	// a corrupted row is found by the readers checking the checksum
+	buf[bytes.Index(buf, []byte("hello"))] = 'j'
+	if err = Validate(buf, ValidateOptions{}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Validate should be ErrChecksumMismatch, err=%+v", err)
+	}
+	if err = ReadEachRow(buf, func(tag int, cols ...interface{}) error { return nil }); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("ReadEachRow should be ErrChecksumMismatch, err=%+v", err)
+	}
+	if _, err = readAllRows(bytes.NewReader(buf)); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("RowReader should be ErrChecksumMismatch, err=%+v", err)
+	}
+}
+
+func TestScanRange(t *testing.T) {
+	rows := make([][]interface{}, 1000)
+	for idx := range rows {
+		rows[idx] = []interface{}{idx, "abc"}
+	}
+	buf, _ := Encode(nil, 1, rows)
+	idx, err := BuildIndex(buf)
+	if err != nil {
+		t.Errorf("BuildIndex error, err=%+v", err)
+		return
+	}
+	var (
+		lock sync.Mutex
+		seen = make(map[int]bool)
+	)
+	err = ScanRange(buf, idx, 100, 900, 8, func(tag int, cols ...interface{}) error {
+		lock.Lock()
+		defer lock.Unlock()
+		if !reflect.DeepEqual(rows[tag-1], cols) {
+			t.Errorf("row %d not equal, %+v", tag-1, cols)
+		}
+		seen[tag-1] = true
+		return nil
+	})
+	if err != nil || len(seen) != 800 || !seen[100] || !seen[899] {
+		t.Errorf("ScanRange error, count=%d, err=%+v", len(seen), err)
+	}
+	stop := errors.New("stop")
+	err = ScanRange(buf, idx, 0, len(idx), 4, func(tag int, cols ...interface{}) error {
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("should stop with error, err=%+v", err)
+	}
+}
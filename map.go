@@ -0,0 +1,208 @@
+package serializer
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// encodeMap encode map[string]interface{} as a group of key/value pairs,
+// keys are sorted to make the output stable
+func encodeMap(buf []byte, tag int, m map[string]interface{}) ([]byte, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	buf = setType(buf, tMap)
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.StartGroupType)
+	var err error
+	for _, k := range keys {
+		buf, err = Encode(buf, tagOfMapKey, k)
+		if err != nil {
+			return buf, debugs.WarpError(err, fmt.Sprintf("encode map key error, key=%s", k))
+		}
+		buf, err = Encode(buf, tagOfMapValue, m[k])
+		if err != nil {
+			return buf, debugs.WarpError(err, fmt.Sprintf("encode map value error, key=%s", k))
+		}
+	}
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.EndGroupType)
+	return buf, nil
+}
+
+// decodeMap decode the key/value pairs after the map group start tag
+func (d *decoder) decodeMap(buf []byte) ([]byte, interface{}, error) {
+	out := make(map[string]interface{})
+	for len(buf) > 0 {
+		_, nextType, nextHeadLen := protowire.ConsumeTag(buf)
+		if nextHeadLen < 0 {
+			return buf, out, fmt.Errorf("[%s]decode map end flag error,code=%d", debugs.SourceCodeLoc(1), nextHeadLen)
+		}
+		if nextType == protowire.EndGroupType {
+			return buf[nextHeadLen:], out, nil
+		}
+		if err := d.addElements(1, 2*sizeOfInterface); err != nil {
+			return buf, out, err
+		}
+		leftData, key, err := d.decode(buf, 0)
+		if err != nil {
+			return buf, out, debugs.WarpError(err, "decode map key error")
+		}
+		k, ok := key.(string)
+		if !ok {
+			return buf, out, fmt.Errorf("[%s]map key not a string, %T", debugs.SourceCodeLoc(1), key)
+		}
+		leftData, value, err := d.decode(leftData, 0)
+		if err != nil {
+			return buf, out, debugs.WarpError(err, fmt.Sprintf("decode map value error, key=%s", k))
+		}
+		buf = leftData
+		out[k] = value
+	}
+	return buf, out, nil
+}
+
+// mapEntry is the range of an encoded key in the key buffer, and the value of a generic map
+type mapEntry struct {
+	start, end int
+	value      reflect.Value
+}
+
+// isMap check if v is a map which should be encoded by encodeReflectMap
+func isMap(v interface{}) (reflect.Value, bool) {
+	rv := reflect.ValueOf(v)
+	return rv, rv.Kind() == reflect.Map && isPlainType(rv.Type())
+}
+
+// basicValue convert a named basic type to the basic type, which Encode can encode directly
+func basicValue(rv reflect.Value) reflect.Value {
+	if basic, ok := basicTypes[rv.Kind()]; ok && rv.Type() != basic && rv.Type() != durationType &&
+		getCustomType(rv.Type()) == nil {
+		return rv.Convert(basic)
+	}
+	return rv
+}
+
+// encodeReflectMap encode a map other than map[string]interface{}.
+// map with string keys is encoded as tMap, others as tAnyMap: each key and value has its own data type,
+// entries are sorted by the encoded keys to make the output stable
+func encodeReflectMap(buf []byte, tag int, rv reflect.Value) ([]byte, error) {
+	var err error
+	if rv.Type().Key().Kind() == reflect.String {
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		buf = setType(buf, tMap)
+		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.StartGroupType)
+		for _, k := range keys {
+			buf, err = Encode(buf, tagOfMapKey, k.String())
+			if err != nil {
+				return buf, debugs.WarpError(err, fmt.Sprintf("encode map key error, key=%s", k.String()))
+			}
+			buf, err = Encode(buf, tagOfMapValue, basicValue(rv.MapIndex(k)).Interface())
+			if err != nil {
+				return buf, debugs.WarpError(err, fmt.Sprintf("encode map value error, key=%s", k.String()))
+			}
+		}
+		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.EndGroupType)
+		return buf, nil
+	}
+	var keyBuf []byte
+	entries := make([]mapEntry, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		start := len(keyBuf)
+		keyBuf, err = Encode(keyBuf, tagOfMapKey, basicValue(iter.Key()).Interface())
+		if err != nil {
+			return buf, debugs.WarpError(err, fmt.Sprintf("encode map key error, key=%+v", iter.Key()))
+		}
+		entries = append(entries, mapEntry{start: start, end: len(keyBuf), value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(keyBuf[entries[i].start:entries[i].end], keyBuf[entries[j].start:entries[j].end]) < 0
+	})
+	buf = setType(buf, tAnyMap)
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.StartGroupType)
+	for i := range entries {
+		e := &entries[i]
+		buf = append(buf, keyBuf[e.start:e.end]...)
+		buf, err = Encode(buf, tagOfMapValue, basicValue(e.value).Interface())
+		if err != nil {
+			return buf, debugs.WarpError(err, "encode map value error")
+		}
+	}
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.EndGroupType)
+	return buf, nil
+}
+
+// isHashable check if the decoded value can be a map key, nil is a valid key of map[interface{}]interface{}
+func isHashable(v interface{}) bool {
+	return v == nil || reflect.TypeOf(v).Comparable()
+}
+
+// decodeAnyMap decode the key/value pairs after the tAnyMap group start tag
+func (d *decoder) decodeAnyMap(buf []byte) ([]byte, interface{}, error) {
+	out := make(map[interface{}]interface{})
+	for len(buf) > 0 {
+		_, nextType, nextHeadLen := protowire.ConsumeTag(buf)
+		if nextHeadLen < 0 {
+			return buf, out, fmt.Errorf("[%s]decode map end flag error,code=%d", debugs.SourceCodeLoc(1), nextHeadLen)
+		}
+		if nextType == protowire.EndGroupType {
+			return buf[nextHeadLen:], out, nil
+		}
+		if err := d.addElements(1, 2*sizeOfInterface); err != nil {
+			return buf, out, err
+		}
+		leftData, key, err := d.decode(buf, 0)
+		if err != nil {
+			return buf, out, debugs.WarpError(err, "decode map key error")
+		}
+		if !isHashable(key) {
+			return buf, out, fmt.Errorf("[%s]map key can not be hashed, %T", debugs.SourceCodeLoc(1), key)
+		}
+		leftData, value, err := d.decode(leftData, 0)
+		if err != nil {
+			return buf, out, debugs.WarpError(err, fmt.Sprintf("decode map value error, key=%+v", key))
+		}
+		buf = leftData
+		out[key] = value
+	}
+	return buf, out, nil
+}
+
+// decodeMapInto decode the key/value pairs after the map group start tag into rv, which is a map of any type
+func decodeMapInto(buf []byte, rv reflect.Value) ([]byte, error) {
+	t := rv.Type()
+	out := reflect.MakeMap(t)
+	rv.Set(out)
+	for len(buf) > 0 {
+		_, nextType, nextHeadLen := protowire.ConsumeTag(buf)
+		if nextHeadLen < 0 {
+			return buf, fmt.Errorf("[%s]decode map end flag error,code=%d", debugs.SourceCodeLoc(1), nextHeadLen)
+		}
+		if nextType == protowire.EndGroupType {
+			return buf[nextHeadLen:], nil
+		}
+		key := reflect.New(t.Key()).Elem()
+		leftData, err := decodeInto(buf, key)
+		if err != nil {
+			return buf, debugs.WarpError(err, fmt.Sprintf("decode key of %s error", t))
+		}
+		if !isHashable(key.Interface()) {
+			return buf, fmt.Errorf("[%s]map key can not be hashed, %T", debugs.SourceCodeLoc(1), key.Interface())
+		}
+		value := reflect.New(t.Elem()).Elem()
+		if leftData, err = decodeInto(leftData, value); err != nil {
+			return buf, debugs.WarpError(err, fmt.Sprintf("decode value of %s error, key=%+v", t, key))
+		}
+		buf = leftData
+		out.SetMapIndex(key, value)
+	}
+	return buf, nil
+}
@@ -0,0 +1,118 @@
+package serializer
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestEncodeDecodeMap(t *testing.T) {
+	m := map[string]interface{}{
+		"int":   -123,
+		"bytes": []byte("AABB"),
+		"str":   "abc",
+		"arr":   []interface{}{uint8(1), "x"},
+		"sub":   map[string]interface{}{"f": float32(1.5)},
+		"empty": map[string]interface{}{},
+	}
+	buf, err := Encode(nil, 1, m)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	leftData, value, err := Decode(buf)
+	if err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	if len(leftData) != 0 {
+		t.Errorf("left data len=%d", len(leftData))
+		return
+	}
+	if !reflect.DeepEqual(m, value) {
+		t.Errorf("not equal, %+v", value)
+		return
+	}
+	// keys are sorted, so the output is stable
+	buf1, err := Encode(nil, 1, m)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual(buf, buf1) {
+		t.Errorf("encode result not stable")
+	}
+}
+
+func TestEncodeDecodeAnyMap(t *testing.T) {
+	type userID int64
+	m1 := map[int64]string{-1: "a", 2: "b", 300: ""}
+	m2 := map[uint32][]byte{1: []byte("x"), 7: {}}
+	m3 := map[userID][]string{10: {"admin", "dev"}}
+	m4 := map[interface{}]interface{}{"k": uint8(1), int8(2): nil, true: []interface{}{"x"}}
+	m5 := map[string]int{"b": 2, "a": 1}
+	buf, err := Encode(nil, 1, []interface{}{m1, m2, m3, m4, m5})
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	if err = Validate(buf, ValidateOptions{}); err != nil {
+		t.Errorf("should be valid, err=%+v", err)
+	}
+	_, value, err := Decode(buf)
+	if err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	expect := []interface{}{
+		map[interface{}]interface{}{int64(-1): "a", int64(2): "b", int64(300): ""},
+		map[interface{}]interface{}{uint32(1): []byte("x"), uint32(7): []byte{}},
+		map[interface{}]interface{}{int64(10): []string{"admin", "dev"}},
+		m4,
+		map[string]interface{}{"a": 1, "b": 2},
+	}
+	if !reflect.DeepEqual(expect, value) {
+		t.Errorf("not equal, %+v", value)
+	}
+	// entries are sorted by the encoded keys, so the output is stable
+	for i := 0; i < 10; i++ {
+		if buf1, _ := Encode(nil, 1, []interface{}{m1, m2, m3, m4, m5}); !reflect.DeepEqual(buf, buf1) {
+			t.Errorf("encode result not stable")
+			return
+		}
+	}
+	type row struct {
+		Counts map[int64]string
+		Keys   map[uint32][]byte
+		Users  map[userID][]string
+		Nested map[int]map[int8]float64
+		Names  map[string]int
+	}
+	r := row{Counts: m1, Keys: m2, Users: m3, Nested: map[int]map[int8]float64{1: {-2: 1.5}}, Names: m5}
+	for _, version := range []int{FormatV1, FormatV2} {
+		buf, err = EncodeVersion(nil, 1, r, version)
+		if err != nil {
+			t.Errorf("encode error, err=%+v", err)
+			return
+		}
+		var out row
+		if _, err = DecodeInto(buf, &out); err != nil || !reflect.DeepEqual(r, out) {
+			t.Errorf("DecodeInto not equal, version=%d, out=%+v, err=%+v", version, out, err)
+		}
+	}
+	var typed map[int64]string
+	buf, _ = Encode(nil, 1, m1)
+	if _, err = DecodeInto(buf, &typed); err != nil || !reflect.DeepEqual(m1, typed) {
+		t.Errorf("DecodeInto not equal, out=%+v, err=%+v", typed, err)
+	}
+	// a key which can not be hashed
+	bad := setType(nil, tAnyMap)
+	bad = protowire.AppendTag(bad, 1, protowire.StartGroupType)
+	bad = append(bad, mustEncode(t, tagOfMapKey, []byte("x"))...)
+	bad = append(bad, mustEncode(t, tagOfMapValue, 1)...)
+	bad = protowire.AppendTag(bad, 1, protowire.EndGroupType)
+	if _, _, err = Decode(bad); err == nil {
+		t.Errorf("should be error")
+	}
+}
@@ -0,0 +1,76 @@
+package serializer
+
+import (
+	"reflect"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// the data types of nil pointers, to decode null as a typed nil pointer
+var nullTypes = map[reflect.Type]uint64{
+	reflect.TypeOf((*bool)(nil)):          tBool,
+	reflect.TypeOf((*int8)(nil)):          tSint8,
+	reflect.TypeOf((*uint8)(nil)):         tUint8,
+	reflect.TypeOf((*int16)(nil)):         tSint16,
+	reflect.TypeOf((*uint16)(nil)):        tUint16,
+	reflect.TypeOf((*int32)(nil)):         tSint32,
+	reflect.TypeOf((*uint32)(nil)):        tUint32,
+	reflect.TypeOf((*int64)(nil)):         tSint64,
+	reflect.TypeOf((*uint64)(nil)):        tUint64,
+	reflect.TypeOf((*int)(nil)):           tSint,
+	reflect.TypeOf((*float32)(nil)):       tFloat32,
+	reflect.TypeOf((*float64)(nil)):       tFloat64,
+	reflect.TypeOf((*string)(nil)):        tString,
+	reflect.TypeOf((*[]byte)(nil)):        tBytes,
+	reflect.TypeOf((*time.Time)(nil)):     tTime,
+	reflect.TypeOf((*time.Duration)(nil)): tDuration,
+}
+
+// the pointer types of data types, reverse of nullTypes
+var nullPointers = func() map[uint64]reflect.Type {
+	m := make(map[uint64]reflect.Type, len(nullTypes))
+	for typ, id := range nullTypes {
+		m[id] = typ
+	}
+	return m
+}()
+
+// isNil check nil interface and nil pointer, typeID is the data type the pointer points to, 0 if unknown
+func isNil(v interface{}) (typeID uint64, ok bool) {
+	if v == nil {
+		return 0, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || !rv.IsNil() {
+		return 0, false
+	}
+	if id, ok := nullTypes[rv.Type()]; ok {
+		return id, true
+	}
+	if t := getCustomType(rv.Type().Elem()); t != nil {
+		return t.id, true
+	}
+	return 0, true
+}
+
+// appendNull write a null value, the varint is the data type the nil pointer points to, 0 for nil interface
+func appendNull(buf []byte, tag int, typeID uint64) []byte {
+	buf = setType(buf, tNull)
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
+	return protowire.AppendVarint(buf, typeID)
+}
+
+// null return the value of null, a typed nil pointer with DecodeOptions.TypedNil if the type is known
+func (d *decoder) null(typeID uint64) interface{} {
+	if !d.opts.TypedNil || typeID == 0 {
+		return nil
+	}
+	if typ, ok := nullPointers[typeID]; ok {
+		return reflect.Zero(typ).Interface()
+	}
+	if t := getCustomTypeByID(typeID); t != nil {
+		return reflect.Zero(reflect.PtrTo(t.typ)).Interface()
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package serializer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNull(t *testing.T) {
+	var (
+		nilInt    *int
+		nilString *string
+		nilTime   *time.Time
+		nilMoney  *testMoney
+		nilStruct *testPoint
+	)
+	data := []interface{}{nil, nilInt, nilString, nilTime, nilMoney, nilStruct, 1}
+	buf, err := Encode(nil, 1, data)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	if err = Validate(buf, ValidateOptions{}); err != nil {
+		t.Errorf("should be valid, err=%+v", err)
+	}
+	_, value, err := Decode(buf)
+	if err != nil || !reflect.DeepEqual([]interface{}{nil, nil, nil, nil, nil, nil, 1}, value) {
+		t.Errorf("decode error, value=%+v, err=%+v", value, err)
+	}
+	_, value, err = DecodeWithOptions(buf, DecodeOptions{TypedNil: true})
+	expect := []interface{}{nil, nilInt, nilString, nilTime, nilMoney, nilStruct, 1} // testPoint is registered
+	if err != nil || !reflect.DeepEqual(expect, value) {
+		t.Errorf("decode error, value=%#v, err=%+v", value, err)
+	}
+	// the values of pointers are still encoded
+	n := 5
+	buf, _ = Encode(nil, 1, []interface{}{&n, nilInt})
+	_, value, _ = Decode(buf)
+	if !reflect.DeepEqual([]interface{}{5, nil}, value) {
+		t.Errorf("decode error, value=%+v", value)
+	}
+	type row struct {
+		A *int
+		B interface{}
+	}
+	var out row
+	buf, _ = Encode(nil, 1, []interface{}{nilInt, nil})
+	if _, err = DecodeInto(buf, &out); err != nil || out.A != nil || out.B != nil {
+		t.Errorf("DecodeInto error, out=%+v, err=%+v", out, err)
+	}
+}
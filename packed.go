@@ -0,0 +1,210 @@
+package serializer
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// encodePacked encode a homogeneous numeric slice as one length-delimited field,
+// the values are back to back varints(zigzag for signed types) or fixed values
+func encodePacked(buf []byte, tag int, v interface{}) []byte {
+	switch v1 := v.(type) {
+	case []int8:
+		size := 0
+		for _, item := range v1 {
+			size += protowire.SizeVarint(protowire.EncodeZigZag(int64(item)))
+		}
+		buf = appendPackedHead(buf, tag, tInt8Slice, size)
+		for _, item := range v1 {
+			buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(item)))
+		}
+	case []int16:
+		size := 0
+		for _, item := range v1 {
+			size += protowire.SizeVarint(protowire.EncodeZigZag(int64(item)))
+		}
+		buf = appendPackedHead(buf, tag, tInt16Slice, size)
+		for _, item := range v1 {
+			buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(item)))
+		}
+	case []uint16:
+		size := 0
+		for _, item := range v1 {
+			size += protowire.SizeVarint(uint64(item))
+		}
+		buf = appendPackedHead(buf, tag, tUint16Slice, size)
+		for _, item := range v1 {
+			buf = protowire.AppendVarint(buf, uint64(item))
+		}
+	case []int32:
+		size := 0
+		for _, item := range v1 {
+			size += protowire.SizeVarint(protowire.EncodeZigZag(int64(item)))
+		}
+		buf = appendPackedHead(buf, tag, tInt32Slice, size)
+		for _, item := range v1 {
+			buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(item)))
+		}
+	case []uint32:
+		size := 0
+		for _, item := range v1 {
+			size += protowire.SizeVarint(uint64(item))
+		}
+		buf = appendPackedHead(buf, tag, tUint32Slice, size)
+		for _, item := range v1 {
+			buf = protowire.AppendVarint(buf, uint64(item))
+		}
+	case []int64:
+		size := 0
+		for _, item := range v1 {
+			size += protowire.SizeVarint(protowire.EncodeZigZag(item))
+		}
+		buf = appendPackedHead(buf, tag, tInt64Slice, size)
+		for _, item := range v1 {
+			buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(item))
+		}
+	case []uint64:
+		size := 0
+		for _, item := range v1 {
+			size += protowire.SizeVarint(item)
+		}
+		buf = appendPackedHead(buf, tag, tUint64Slice, size)
+		for _, item := range v1 {
+			buf = protowire.AppendVarint(buf, item)
+		}
+	case []int:
+		size := 0
+		for _, item := range v1 {
+			size += protowire.SizeVarint(protowire.EncodeZigZag(int64(item)))
+		}
+		buf = appendPackedHead(buf, tag, tIntSlice, size)
+		for _, item := range v1 {
+			buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(item)))
+		}
+	case []bool:
+		buf = appendPackedHead(buf, tag, tBoolSlice, len(v1))
+		for _, item := range v1 {
+			buf = protowire.AppendVarint(buf, protowire.EncodeBool(item))
+		}
+	case []float32:
+		buf = appendPackedHead(buf, tag, tFloat32Slice, len(v1)*4)
+		for _, item := range v1 {
+			buf = protowire.AppendFixed32(buf, math.Float32bits(item))
+		}
+	case []float64:
+		buf = appendPackedHead(buf, tag, tFloat64Slice, len(v1)*8)
+		for _, item := range v1 {
+			buf = protowire.AppendFixed64(buf, math.Float64bits(item))
+		}
+	}
+	return buf
+}
+
+func appendPackedHead(buf []byte, tag int, t uint64, size int) []byte {
+	buf = setType(buf, t)
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.BytesType)
+	buf = protowire.AppendVarint(buf, uint64(size))
+	return buf
+}
+
+// decodePacked decode the content of a packed field to a typed slice
+func decodePacked(data []byte, golangType uint64) (interface{}, error) {
+	switch golangType {
+	case tFloat32Slice:
+		if len(data)%4 != 0 {
+			return nil, fmt.Errorf("[%s]packed float32 length error, len=%d", debugs.SourceCodeLoc(1), len(data))
+		}
+		out := make([]float32, 0, len(data)/4)
+		for len(data) > 0 {
+			v, n := protowire.ConsumeFixed32(data)
+			data = data[n:]
+			out = append(out, math.Float32frombits(v))
+		}
+		return out, nil
+	case tFloat64Slice:
+		if len(data)%8 != 0 {
+			return nil, fmt.Errorf("[%s]packed float64 length error, len=%d", debugs.SourceCodeLoc(1), len(data))
+		}
+		out := make([]float64, 0, len(data)/8)
+		for len(data) > 0 {
+			v, n := protowire.ConsumeFixed64(data)
+			data = data[n:]
+			out = append(out, math.Float64frombits(v))
+		}
+		return out, nil
+	case tBoolSlice:
+		out := make([]bool, 0, len(data))
+		for _, c := range data {
+			if c > 1 {
+				return nil, fmt.Errorf("[%s]not a bool value, %d", debugs.SourceCodeLoc(1), c)
+			}
+			out = append(out, c == 1)
+		}
+		return out, nil
+	}
+	count := packedVarintCount(data)
+	var err error
+	switch golangType {
+	case tInt8Slice:
+		out := make([]int8, 0, count)
+		err = consumePackedVarints(data, func(v uint64) { out = append(out, int8(protowire.DecodeZigZag(v))) })
+		return out, err
+	case tInt16Slice:
+		out := make([]int16, 0, count)
+		err = consumePackedVarints(data, func(v uint64) { out = append(out, int16(protowire.DecodeZigZag(v))) })
+		return out, err
+	case tUint16Slice:
+		out := make([]uint16, 0, count)
+		err = consumePackedVarints(data, func(v uint64) { out = append(out, uint16(v)) })
+		return out, err
+	case tInt32Slice:
+		out := make([]int32, 0, count)
+		err = consumePackedVarints(data, func(v uint64) { out = append(out, int32(protowire.DecodeZigZag(v))) })
+		return out, err
+	case tUint32Slice:
+		out := make([]uint32, 0, count)
+		err = consumePackedVarints(data, func(v uint64) { out = append(out, uint32(v)) })
+		return out, err
+	case tInt64Slice:
+		out := make([]int64, 0, count)
+		err = consumePackedVarints(data, func(v uint64) { out = append(out, protowire.DecodeZigZag(v)) })
+		return out, err
+	case tUint64Slice:
+		out := make([]uint64, 0, count)
+		err = consumePackedVarints(data, func(v uint64) { out = append(out, v) })
+		return out, err
+	case tIntSlice:
+		out := make([]int, 0, count)
+		err = consumePackedVarints(data, func(v uint64) { out = append(out, int(protowire.DecodeZigZag(v))) })
+		return out, err
+	default:
+		return nil, fmt.Errorf("[%s]not a packed type, %d", debugs.SourceCodeLoc(1), golangType)
+	}
+}
+
+func consumePackedVarints(data []byte, fn func(v uint64)) error {
+	for len(data) > 0 {
+		v, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return fmt.Errorf("[%s]read packed varint error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		data = data[n:]
+		fn(v)
+	}
+	return nil
+}
+
+// packedVarintCount count the varints in packed data, each varint ends with a byte less than 0x80
+func packedVarintCount(data []byte) int {
+	count := 0
+	for _, c := range data {
+		if c < 0x80 {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,60 @@
+package serializer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncodeDecodePacked(t *testing.T) {
+	arr := []interface{}{
+		[]int8{-1, 2, -128, 127},
+		[]int16{-300, 0, 300},
+		[]uint16{65535, 1},
+		[]int32{-99, 1 << 30},
+		[]uint32{0x7f09, 0},
+		[]int64{-18, 1 << 40, -1 << 62},
+		[]uint64{17, 1 << 63},
+		[]int{-19, 19},
+		[]float32{-110.1, 10.1},
+		[]float64{-111.2, 11.2},
+		[]int64{},
+	}
+	buf, err := Encode(nil, 1, arr)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	_, values, err := Decode(buf)
+	if err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual(arr, values) {
+		t.Errorf("not equal, %+v", values)
+		return
+	}
+	// type, tag, length, then 1 byte for each small value
+	buf, err = Encode(nil, 1, []int64{1, -1, 2, -2})
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	if len(buf) != 2+1+1+4 {
+		t.Errorf("packed size not match, len=%d", len(buf))
+	}
+}
+
+func BenchmarkDecodePacked(b *testing.B) {
+	v := make([]int64, 1000)
+	for i := range v {
+		v[i] = int64(i - 500)
+	}
+	buf, _ := Encode(nil, 1, v)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := Decode(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package serializer
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// ReadEachRowProjected read rows like ReadEachRow, but only decode the columns at cols(index from 0),
+// the other cells are skipped at the wire level. callback get the selected columns in the order of cols.
+func ReadEachRowProjected(buf []byte, cols []int, callback RowCallback) error {
+	for _, col := range cols {
+		if col < 0 {
+			return fmt.Errorf("[%s]invalid column %d", debugs.SourceCodeLoc(1), col)
+		}
+	}
+	it := NewRowIterator(buf)
+	it.proj = cols
+	if it.proj == nil {
+		it.proj = []int{}
+	}
+	for it.Next() {
+		tag, values := it.Row()
+		if err := callback(tag, values...); err != nil {
+			return debugs.WarpError(err, "callback error")
+		}
+	}
+	return it.Err()
+}
+
+// decodeProjected decode the items at cols of the array row with the limits of d, row is in V1
+func decodeProjected(d *decoder, row []byte, cols []int) ([]interface{}, error) {
+	golangType, _, typ, n := consumeHead(row)
+	if n < 0 {
+		return nil, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	if typ != protowire.StartGroupType || golangType != 0 {
+		return nil, fmt.Errorf("[%s]row not a array, type=%d, data type=%d", debugs.SourceCodeLoc(1), typ, golangType)
+	}
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+	maxCol := -1
+	for _, col := range cols {
+		if col > maxCol {
+			maxCol = col
+		}
+	}
+	out := make([]interface{}, len(cols))
+	buf := row[n:]
+	for pos := 0; pos <= maxCol; {
+		golangType, _, typ, n := consumeHead(buf)
+		if n < 0 {
+			return nil, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		if typ == protowire.EndGroupType {
+			return nil, debugs.WarpError(ErrPathNotFound, fmt.Sprintf("column %d not in row of %d columns", maxCol, pos))
+		}
+		if golangType == tChecksum || golangType == tIndex {
+			leftData, err := skipValue(buf)
+			if err != nil {
+				return nil, debugs.WarpError(err, "skip trailer error")
+			}
+			buf = leftData
+			continue
+		}
+		wanted := false
+		for _, col := range cols {
+			wanted = wanted || col == pos
+		}
+		if !wanted {
+			leftData, err := skipValue(buf)
+			if err != nil {
+				return nil, debugs.WarpError(err, fmt.Sprintf("skip column %d error", pos))
+			}
+			buf = leftData
+			pos++
+			continue
+		}
+		leftData, value, err := d.decode(buf, 0)
+		if err != nil {
+			return nil, debugs.WarpError(err, fmt.Sprintf("decode column %d error", pos))
+		}
+		for k, col := range cols {
+			if col == pos {
+				out[k] = value
+			}
+		}
+		buf = leftData
+		pos++
+	}
+	return out, nil
+}
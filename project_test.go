@@ -0,0 +1,67 @@
+package serializer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReadEachRowProjected(t *testing.T) {
+	rows := getTestRows()
+	buf, _ := EncodeWithChecksum(nil, 1, rows)
+	cols := []int{3, 0, 3}
+	idx := 0
+	err := ReadEachRowProjected(buf, cols, func(tag int, values ...interface{}) error {
+		expect := []interface{}{rows[idx][3], rows[idx][0], rows[idx][3]}
+		if tag != idx+1 || !reflect.DeepEqual(expect, values) {
+			t.Errorf("row %d not equal, tag=%d, values=%+v", idx, tag, values)
+		}
+		idx++
+		return nil
+	})
+	if err != nil || idx != len(rows) {
+		t.Errorf("ReadEachRowProjected error, count=%d, err=%+v", idx, err)
+	}
+	err = ReadEachRowProjected(buf, nil, func(tag int, values ...interface{}) error {
+		if len(values) != 0 {
+			t.Errorf("should have no column, %+v", values)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("ReadEachRowProjected error, err=%+v", err)
+	}
+	err = ReadEachRowProjected(buf, []int{len(rows[0])}, func(tag int, values ...interface{}) error {
+		return nil
+	})
+	if !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("should be ErrPathNotFound, err=%+v", err)
+	}
+}
+
+func BenchmarkReadEachRowProjected(b *testing.B) {
+	rows := make([][]interface{}, 1000)
+	for idx := range rows {
+		rows[idx] = []interface{}{idx, "abc", 1.5, map[string]interface{}{"a": "b"}, []interface{}{1, 2, 3}}
+	}
+	buf, _ := Encode(nil, 1, rows)
+	callback := func(tag int, cols ...interface{}) error {
+		return nil
+	}
+	b.Run("all", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := ReadEachRow(buf, callback); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("projected", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := ReadEachRowProjected(buf, []int{0, 2}, callback); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package serializer
+
+import (
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// RawMessage is one encoded value in FormatV1, with its data type field and tag, like the output of Encode.
+// Encode copy it verbatim with the new tag, so a cached or forwarded value is not decoded and encoded again.
+// Decode return the nested arrays and maps as RawMessage with DecodeOptions.RawMessages.
+type RawMessage []byte
+
+var rawMessageType = reflect.TypeOf(RawMessage(nil))
+
+// encodeRawMessage copy the value of msg with the tag, the tags of a group start and end are replaced
+func encodeRawMessage(buf []byte, tag int, msg RawMessage) ([]byte, error) {
+	golangType, oldTag, typ, n := consumeHead(msg)
+	if n < 0 {
+		return buf, fmt.Errorf("[%s]read RawMessage error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	valueLen := protowire.ConsumeFieldValue(oldTag, typ, msg[n:])
+	if valueLen < 0 {
+		return buf, fmt.Errorf("[%s]read RawMessage value error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+	}
+	if n+valueLen != len(msg) {
+		return buf, fmt.Errorf("[%s]RawMessage is not one value, %d bytes left", debugs.SourceCodeLoc(1), len(msg)-n-valueLen)
+	}
+	if golangType != 0 {
+		buf = setType(buf, golangType)
+	}
+	buf = protowire.AppendTag(buf, protowire.Number(tag), typ)
+	if typ != protowire.StartGroupType {
+		return append(buf, msg[n:]...), nil
+	}
+	buf = append(buf, msg[n:len(msg)-protowire.SizeTag(oldTag)]...)
+	return protowire.AppendTag(buf, protowire.Number(tag), protowire.EndGroupType), nil
+}
@@ -0,0 +1,90 @@
+package serializer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestRawMessage(t *testing.T) {
+	data := getTestData()
+	expect, _ := Encode(nil, 1, data)
+	// the cached items are copied with new tags
+	items := make([]interface{}, len(data))
+	for idx, item := range data {
+		cached, err := Encode(nil, 100+idx, item)
+		if err != nil {
+			t.Errorf("encode error, err=%+v", err)
+			return
+		}
+		items[idx] = RawMessage(cached)
+	}
+	buf, err := Encode(nil, 1, items)
+	if err != nil || !bytes.Equal(expect, buf) {
+		t.Errorf("not equal, err=%+v\n%x\n%x", err, expect, buf)
+	}
+	scalar, _ := Encode(nil, 3, "abc")
+	buf, err = Encode(nil, 1, []interface{}{1, RawMessage(scalar)})
+	expect, _ = Encode(nil, 1, []interface{}{1, "abc"})
+	if err != nil || !bytes.Equal(expect, buf) {
+		t.Errorf("not equal, err=%+v\n%x\n%x", err, expect, buf)
+	}
+	if _, err = Encode(nil, 1, RawMessage(append(scalar, scalar...))); err == nil {
+		t.Errorf("RawMessage with two values should fail")
+	}
+	if _, err = Encode(nil, 1, RawMessage(scalar[:len(scalar)-1])); err == nil {
+		t.Errorf("truncated RawMessage should fail")
+	}
+}
+
+func TestDecodeRawMessages(t *testing.T) {
+	data := getTestData()
+	for _, version := range []int{FormatV1, FormatV2} {
+		buf, _ := EncodeVersion(nil, 1, data, version)
+		_, value, err := DecodeWithOptions(buf, DecodeOptions{RawMessages: true})
+		if err != nil {
+			t.Errorf("decode error, err=%+v", err)
+			return
+		}
+		items := value.([]interface{})
+		for idx, item := range items {
+			msg, ok := item.(RawMessage)
+			if !ok {
+				t.Errorf("item %d not a RawMessage, %T", idx, item)
+				continue
+			}
+			_, itemValue, err := Decode(msg)
+			if err != nil || !reflect.DeepEqual(data[idx], itemValue) {
+				t.Errorf("item %d not equal, value=%+v, err=%+v", idx, itemValue, err)
+			}
+		}
+		// forward without decoding
+		out, err := Encode(nil, 1, items)
+		expect, _ := Encode(nil, 1, data)
+		if err != nil || !bytes.Equal(expect, out) {
+			t.Errorf("forward not equal, err=%+v", err)
+		}
+	}
+	type wrapper struct {
+		Name string
+		Body RawMessage
+	}
+	buf, _ := Encode(nil, 1, wrapper{Name: "a", Body: RawMessage(mustEncode(t, 1, data[0]))})
+	var w wrapper
+	if _, err := DecodeInto(buf, &w); err != nil || w.Name != "a" {
+		t.Errorf("DecodeInto error, w=%+v, err=%+v", w, err)
+		return
+	}
+	_, body, err := Decode(w.Body)
+	if err != nil || !reflect.DeepEqual(data[0], body) {
+		t.Errorf("body not equal, value=%+v, err=%+v", body, err)
+	}
+}
+
+func mustEncode(t *testing.T, tag int, v interface{}) []byte {
+	buf, err := Encode(nil, tag, v)
+	if err != nil {
+		t.Fatalf("encode error, err=%+v", err)
+	}
+	return buf
+}
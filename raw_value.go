@@ -0,0 +1,51 @@
+package serializer
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// RawValue is a value of unknown data type, it is decoded in the lenient mode(DecodeOptions.KeepUnknownTypes),
+// and Encode write it back byte-for-byte, so the data from a newer writer can pass through.
+type RawValue struct {
+	TypeID   uint64
+	WireType protowire.Type
+	// Bytes is the encoded value after the tag: the varint, fixed value or length-prefixed bytes,
+	// for a group, it is the items without the end tag
+	Bytes []byte
+}
+
+// encodeRawValue write a RawValue with the tag
+func encodeRawValue(buf []byte, tag int, v *RawValue) ([]byte, error) {
+	if v.WireType == protowire.EndGroupType {
+		return buf, fmt.Errorf("[%s]RawValue can not be a group end", debugs.SourceCodeLoc(1))
+	}
+	if v.TypeID != 0 {
+		buf = setType(buf, v.TypeID)
+	}
+	buf = protowire.AppendTag(buf, protowire.Number(tag), v.WireType)
+	buf = append(buf, v.Bytes...)
+	if v.WireType == protowire.StartGroupType {
+		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.EndGroupType)
+	}
+	return buf, nil
+}
+
+// decodeRawValue keep the value after the tag as RawValue
+func (d *decoder) decodeRawValue(buf []byte, golangType uint64, tag protowire.Number, typ protowire.Type) ([]byte, interface{}, error) {
+	n := protowire.ConsumeFieldValue(tag, typ, buf)
+	if n < 0 {
+		return buf, nil, fmt.Errorf("[%s]read unknown data type %d error,code=%d", debugs.SourceCodeLoc(1), golangType, n)
+	}
+	value := buf[:n]
+	if typ == protowire.StartGroupType {
+		value = value[:n-protowire.SizeTag(tag)]
+	}
+	if err := d.checkString(len(value)); err != nil {
+		return buf, nil, err
+	}
+	return buf[n:], RawValue{TypeID: golangType, WireType: typ, Bytes: value}, nil
+}
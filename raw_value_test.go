@@ -0,0 +1,77 @@
+package serializer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestRawValue(t *testing.T) {
+	// a buffer from a newer writer with unknown data types
+	buf := AppendArrayStart(nil, 1)
+	buf, _ = Encode(buf, 1, "abc")
+	buf = setType(buf, 1000)
+	buf = protowire.AppendTag(buf, 2, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, 123456)
+	buf = setType(buf, 200)
+	buf = protowire.AppendTag(buf, 3, protowire.BytesType)
+	buf = protowire.AppendString(buf, "new type")
+	buf = setType(buf, 300)
+	buf = protowire.AppendTag(buf, 4, protowire.StartGroupType)
+	buf, _ = Encode(buf, 1, 1)
+	buf, _ = Encode(buf, 2, []interface{}{"x"})
+	buf = protowire.AppendTag(buf, 4, protowire.EndGroupType)
+	buf = AppendArrayEnd(buf, 1)
+
+	if _, _, err := Decode(buf); err == nil {
+		t.Errorf("unknown data type should fail in strict mode")
+	}
+	if err := Validate(buf, ValidateOptions{}); err == nil {
+		t.Errorf("unknown data type should be invalid in strict mode")
+	}
+	if err := Validate(buf, ValidateOptions{AllowUnknownTypes: true}); err != nil {
+		t.Errorf("should be valid, err=%+v", err)
+	}
+	_, value, err := DecodeWithOptions(buf, DecodeOptions{KeepUnknownTypes: true})
+	if err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	items := value.([]interface{})
+	if len(items) != 4 || items[0] != "abc" {
+		t.Errorf("decode error, %+v", items)
+		return
+	}
+	if raw, ok := items[1].(RawValue); !ok || raw.TypeID != 1000 || raw.WireType != protowire.VarintType {
+		t.Errorf("not a RawValue, %+v", items[1])
+	}
+	if raw, ok := items[3].(RawValue); !ok || raw.TypeID != 300 || raw.WireType != protowire.StartGroupType {
+		t.Errorf("not a RawValue, %+v", items[3])
+	}
+	// pass through
+	out, err := Encode(nil, 1, items)
+	if err != nil || !bytes.Equal(buf, out) {
+		t.Errorf("not equal, err=%+v\n%x\n%x", err, buf, out)
+	}
+	// the tag of group is changed
+	out, err = Encode(nil, 5, items[3])
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+	}
+	_, value, err = DecodeWithOptions(out, DecodeOptions{KeepUnknownTypes: true})
+	if err != nil || !reflect.DeepEqual(items[3], value) {
+		t.Errorf("not equal, value=%+v, err=%+v", value, err)
+	}
+	// V2
+	v2, _, err := convertValue(AppendHeader(nil, FormatV2), buf, FormatV1, FormatV2)
+	if err != nil {
+		t.Errorf("convert error, err=%+v", err)
+		return
+	}
+	_, value, err = DecodeWithOptions(v2, DecodeOptions{KeepUnknownTypes: true})
+	if err != nil || !reflect.DeepEqual(items, value) {
+		t.Errorf("V2 not equal, value=%+v, err=%+v", value, err)
+	}
+}
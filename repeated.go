@@ -0,0 +1,80 @@
+package serializer
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// tagOfRepeated is the tag of items in []string and [][]byte groups
+const tagOfRepeated = 1
+
+// encodeStrings encode []string as a group of repeated bytes fields without data type field
+func encodeStrings(buf []byte, tag int, v []string) []byte {
+	buf = setType(buf, tStringSlice)
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.StartGroupType)
+	for _, item := range v {
+		buf = protowire.AppendTag(buf, tagOfRepeated, protowire.BytesType)
+		buf = protowire.AppendString(buf, item)
+	}
+	return protowire.AppendTag(buf, protowire.Number(tag), protowire.EndGroupType)
+}
+
+// encodeBytesSlice encode [][]byte as a group of repeated bytes fields without data type field
+func encodeBytesSlice(buf []byte, tag int, v [][]byte) []byte {
+	buf = setType(buf, tBytesSlice)
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.StartGroupType)
+	for _, item := range v {
+		buf = protowire.AppendTag(buf, tagOfRepeated, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, item)
+	}
+	return protowire.AppendTag(buf, protowire.Number(tag), protowire.EndGroupType)
+}
+
+// decodeRepeated decode the items after the group start tag to []string or [][]byte
+func (d *decoder) decodeRepeated(buf []byte, golangType uint64) ([]byte, interface{}, error) {
+	var (
+		strs  []string
+		bytes [][]byte
+	)
+	if golangType == tStringSlice {
+		strs = make([]string, 0, defaultArrayCount)
+	} else {
+		bytes = make([][]byte, 0, defaultArrayCount)
+	}
+	for len(buf) > 0 {
+		_, typ, n := protowire.ConsumeTag(buf)
+		if n < 0 {
+			return buf, nil, fmt.Errorf("[%s]read repeated item error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		if typ == protowire.EndGroupType {
+			buf = buf[n:]
+			break
+		}
+		if typ != protowire.BytesType {
+			return buf, nil, fmt.Errorf("[%s]repeated item not a BytesType, type=%d", debugs.SourceCodeLoc(1), typ)
+		}
+		item, m := protowire.ConsumeBytes(buf[n:])
+		if m < 0 {
+			return buf, nil, fmt.Errorf("[%s]read BytesType error,code=%d", debugs.SourceCodeLoc(1), m)
+		}
+		if err := d.addElements(1, sizeOfInterface); err != nil {
+			return buf, nil, err
+		}
+		if err := d.checkString(len(item)); err != nil {
+			return buf, nil, err
+		}
+		buf = buf[n+m:]
+		if strs != nil {
+			strs = append(strs, string(item))
+		} else {
+			bytes = append(bytes, item)
+		}
+	}
+	if strs != nil {
+		return buf, strs, nil
+	}
+	return buf, bytes, nil
+}
@@ -0,0 +1,58 @@
+package serializer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRepeated(t *testing.T) {
+	input := []interface{}{
+		[]string{"a", "", "中文"},
+		[]string{},
+		[][]byte{[]byte("key1"), {}, {0, 1, 2}},
+		[]bool{true, false, true},
+		[]bool{},
+	}
+	for _, version := range []int{FormatV1, FormatV2} {
+		buf, err := EncodeVersion(nil, 1, input, version)
+		if err != nil {
+			t.Errorf("encode error, err=%+v", err)
+			return
+		}
+		if err = Validate(buf, ValidateOptions{}); err != nil {
+			t.Errorf("should be valid, version=%d, err=%+v", version, err)
+		}
+		_, value, err := Decode(buf)
+		if err != nil {
+			t.Errorf("decode error, version=%d, err=%+v", version, err)
+			return
+		}
+		if !reflect.DeepEqual(input, value) {
+			t.Errorf("not equal, version=%d, %+v", version, value)
+		}
+	}
+	type labels struct {
+		Names []string
+		Keys  [][]byte
+		Flags []bool
+	}
+	l := labels{Names: []string{"x", "y"}, Keys: [][]byte{[]byte("k")}, Flags: []bool{false}}
+	buf, _ := Encode(nil, 1, l)
+	var out labels
+	if _, err := DecodeInto(buf, &out); err != nil || !reflect.DeepEqual(l, out) {
+		t.Errorf("DecodeInto not equal, out=%+v, err=%+v", out, err)
+	}
+	if _, _, err := DecodeWithOptions(buf, DecodeOptions{MaxElements: 3}); !errors.Is(err, ErrMaxElements) {
+		t.Errorf("should hit element limit, err=%+v", err)
+	}
+	// a packed bool must be 0 or 1
+	bad, _ := Encode(nil, 1, []bool{true})
+	bad[len(bad)-1] = 2
+	if _, _, err := Decode(bad); err == nil {
+		t.Errorf("should be error")
+	}
+	if err := Validate(bad, ValidateOptions{}); err == nil {
+		t.Errorf("should be invalid")
+	}
+}
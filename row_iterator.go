@@ -0,0 +1,120 @@
+package serializer
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// RowIterator read rows one by one, like ReadEachRow but pull-style:
+//
+//	it := NewRowIterator(buf)
+//	for it.Next() {
+//		tag, cols := it.Row()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type RowIterator struct {
+	data []byte // V1 data without header and compression frame
+	pos  int    // offset of next row in data
+	end  int    // offset of the array end flag in data
+	tag  int
+	cols []interface{}
+	proj []int         // the columns to decode, nil means all
+	opts DecodeOptions // limits of decoding each row
+
+	filter Filter   // rows not match are skipped without decoding
+	cells  [][]byte // raw cells of current row, for filter
+	err    error
+}
+
+// NewRowIterator create a RowIterator, the error of reading the array is returned by Err
+func NewRowIterator(buf []byte) *RowIterator {
+	return newRowIterator(buf, DecodeOptions{})
+}
+
+// newRowIterator create a RowIterator with the limits of opts, which apply to the compression frame and each row
+func newRowIterator(buf []byte, opts DecodeOptions) *RowIterator {
+	it := &RowIterator{opts: opts}
+	d := decoder{opts: opts}
+	data, err := d.plainData(buf)
+	if err != nil {
+		it.err = debugs.WarpError(err, "plainData error")
+		return it
+	}
+	arrayData, headLen, _, tag, err := ReadArray(data)
+	if err != nil {
+		it.err = debugs.WarpError(err, "ReadArray error")
+		return it
+	}
+	it.data = data
+	it.pos = headLen
+	it.end = len(arrayData) - protowire.SizeTag(protowire.Number(tag))
+	return it
+}
+
+// Next read next row, it returns false at the end of array or on error
+func (it *RowIterator) Next() bool {
+	it.tag, it.cols = 0, nil
+	for {
+		if it.err != nil || it.pos >= it.end {
+			return false
+		}
+		item := it.data[it.pos:it.end]
+		if golangType, _, _, n := consumeHead(item); n > 0 && (golangType == tChecksum || golangType == tIndex) {
+			it.pos = it.end // the trailers, checksum is checked by ReadArray
+			return false
+		}
+		arrayData, _, leftData, tag, err := ReadArray(item)
+		if err != nil {
+			it.err = debugs.WarpError(err, "ReadArray read row error")
+			return false
+		}
+		it.pos = it.end - len(leftData)
+		if it.filter != nil {
+			matched, err := it.match(arrayData)
+			if err != nil {
+				it.err = debugs.WarpError(err, "filter row error")
+				return false
+			}
+			if !matched {
+				continue
+			}
+		}
+		it.tag = tag
+		if it.proj != nil {
+			if it.cols, err = decodeProjected(&decoder{opts: it.opts}, arrayData, it.proj); err != nil {
+				it.err = debugs.WarpError(err, "decodeProjected row error")
+				return false
+			}
+			return true
+		}
+		_, values, err := DecodeWithOptions(arrayData, it.opts)
+		if err != nil {
+			it.err = debugs.WarpError(err, "Decode row error")
+			return false
+		}
+		if cols, ok := values.([]interface{}); ok {
+			it.cols = cols
+		} else {
+			it.cols = []interface{}{values}
+		}
+		return true
+	}
+}
+
+// Row return the row read by Next, if the row is not an array, cols is the only value of row
+func (it *RowIterator) Row() (tag int, cols []interface{}) {
+	return it.tag, it.cols
+}
+
+// Err return the first error of the iterator
+func (it *RowIterator) Err() error {
+	return it.err
+}
+
+// Offset return the offset of next row in the V1 data of the array,
+// it is the offset in buf if buf is V1 without header and compression frame
+func (it *RowIterator) Offset() int {
+	return it.pos
+}
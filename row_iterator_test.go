@@ -0,0 +1,69 @@
+package serializer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRowIterator(t *testing.T) {
+	rows := getTestRows()
+	buf, err := Encode(nil, 1, rows)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	it := NewRowIterator(buf)
+	var offsets []int
+	for idx := 0; it.Next(); idx++ {
+		tag, cols := it.Row()
+		if tag != idx+1 || !reflect.DeepEqual(rows[idx], cols) {
+			t.Errorf("row %d not equal, tag=%d, cols=%+v", idx, tag, cols)
+		}
+		offsets = append(offsets, it.Offset())
+	}
+	if it.Err() != nil {
+		t.Errorf("iterate error, err=%+v", it.Err())
+	}
+	if len(offsets) != len(rows) || offsets[len(offsets)-1] != len(buf)-1 {
+		t.Errorf("offsets error, %+v", offsets)
+	}
+	// the rest of buffer can be read from the offset
+	_, left, err := Decode(buf[offsets[0]:offsets[1]])
+	if err != nil || !reflect.DeepEqual(rows[1], left) {
+		t.Errorf("read from offset error, value=%+v, err=%+v", left, err)
+	}
+	// early exit
+	it = NewRowIterator(buf)
+	if !it.Next() {
+		t.Errorf("should have a row")
+	}
+	if it.Err() != nil {
+		t.Errorf("iterate error, err=%+v", it.Err())
+	}
+	// V2 and checksum
+	v2, _ := EncodeVersion(nil, 1, rows, FormatV2)
+	withChecksum, _ := EncodeWithChecksum(nil, 1, rows)
+	for _, data := range [][]byte{v2, withChecksum} {
+		it = NewRowIterator(data)
+		count := 0
+		for it.Next() {
+			count++
+		}
+		if it.Err() != nil || count != len(rows) {
+			t.Errorf("iterate error, count=%d, err=%+v", count, it.Err())
+		}
+	}
+}
+
+func TestRowIteratorError(t *testing.T) {
+	buf, _ := Encode(nil, 1, getTestRows())
+	it := NewRowIterator(buf[:len(buf)-1])
+	if it.Next() || it.Err() == nil {
+		t.Errorf("should be error")
+	}
+	buf, _ = Encode(nil, 1, []interface{}{[]interface{}{1}, 2})
+	it = NewRowIterator(buf)
+	if !it.Next() || it.Next() || it.Err() == nil {
+		t.Errorf("row not a array should be error")
+	}
+}
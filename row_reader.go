@@ -0,0 +1,242 @@
+package serializer
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+const (
+	defaultRowReaderBufferSize = 4 * 1024
+	defaultRowReaderMaxRowSize = 64 * 1024 * 1024
+)
+
+// RowReaderOptions config of RowReader, zero value means default
+type RowReaderOptions struct {
+	BufferSize int // initial size of the buffer, default is 4KB, no more than MaxRowSize
+	MaxRowSize int // max size of one row, the buffer never grows beyond it, default is 64MB
+}
+
+// RowReader read rows from io.Reader streamly, only the bytes of current row are buffered.
+// the header, V2 format and CRC32C trailer are supported, the compression frame and columnar format are not.
+type RowReader struct {
+	r       io.Reader
+	opts    RowReaderOptions
+	buf     []byte
+	pos     int // buf[pos:] is not consumed
+	eof     bool
+	version int
+	tag     int // tag of the outer array
+	started bool
+	done    bool
+	crc     uint32 // CRC32C of the V1 body
+	err     error
+}
+
+// NewRowReader create a RowReader
+func NewRowReader(r io.Reader, opts RowReaderOptions) *RowReader {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultRowReaderBufferSize
+	}
+	if opts.MaxRowSize <= 0 {
+		opts.MaxRowSize = defaultRowReaderMaxRowSize
+	}
+	if opts.BufferSize > opts.MaxRowSize {
+		opts.BufferSize = opts.MaxRowSize
+	}
+	return &RowReader{
+		r:    r,
+		opts: opts,
+		buf:  make([]byte, 0, opts.BufferSize),
+	}
+}
+
+// ReadRow read next row, it returns io.EOF after the end of array.
+// if the row is not an array, cols is the only value of row, same as ReadEachRow.
+func (rr *RowReader) ReadRow() (tag int, cols []interface{}, err error) {
+	if rr.err != nil {
+		return 0, nil, rr.err
+	}
+	tag, cols, err = rr.readRow()
+	if err != nil {
+		rr.err = err
+	}
+	return
+}
+
+func (rr *RowReader) readRow() (int, []interface{}, error) {
+	if rr.done {
+		return 0, nil, io.EOF
+	}
+	if !rr.started {
+		if err := rr.readStart(); err != nil {
+			return 0, nil, err
+		}
+	}
+	for {
+		var (
+			golangType uint64
+			num        protowire.Number
+			typ        protowire.Type
+			headLen    int
+		)
+		itemLen, err := rr.peek(func(data []byte) int {
+			golangType, num, typ, headLen = readHead(data, rr.version)
+			if headLen < 0 || typ == protowire.EndGroupType {
+				return headLen
+			}
+			return consumeValue(data, rr.version)
+		})
+		if err != nil {
+			return 0, nil, debugs.WarpError(err, "read row error")
+		}
+		item := rr.buf[rr.pos : rr.pos+itemLen]
+		rr.pos += itemLen
+		if typ == protowire.EndGroupType {
+			if int(num) != rr.tag {
+				return 0, nil, fmt.Errorf("[%s]array end tag %d not match %d", debugs.SourceCodeLoc(1), num, rr.tag)
+			}
+			rr.done = true
+			return 0, nil, io.EOF
+		}
+		if golangType == tChecksum {
+			sum, _, err := readChecksum(item, rr.version)
+			if err != nil {
+				return 0, nil, debugs.WarpError(err, "readChecksum error")
+			}
+			if sum != rr.crc {
+				return 0, nil, debugs.WarpError(ErrChecksumMismatch, fmt.Sprintf("expect %08x", sum))
+			}
+			continue
+		}
+		if golangType == tIndex {
+			continue
+		}
+		// the bytes of row are copied, so the values are still valid after the buffer is reused
+		var row []byte
+		if rr.version == FormatV1 {
+			row = append(make([]byte, 0, len(item)), item...)
+		} else {
+			if row, _, err = convertValue(make([]byte, 0, len(item)+len(item)/2), item, rr.version, FormatV1); err != nil {
+				return 0, nil, debugs.WarpError(err, "convert row to V1 error")
+			}
+		}
+		rr.crc = crc32.Update(rr.crc, crc32cTable, row)
+		_, value, err := decode(row, 0)
+		if err != nil {
+			return 0, nil, debugs.WarpError(err, "decode row error")
+		}
+		if cols, ok := value.([]interface{}); ok {
+			return int(num), cols, nil
+		}
+		return int(num), []interface{}{value}, nil
+	}
+}
+
+// readStart read the header and the array start tag
+func (rr *RowReader) readStart() error {
+	headerLen, err := rr.peek(func(data []byte) int {
+		if len(data) < len(headerMagic) && !rr.eof && bytes.HasPrefix(headerMagic, data) {
+			return -1 // truncated
+		}
+		if !bytes.HasPrefix(data, headerMagic) {
+			return 0
+		}
+		_, n := protowire.ConsumeVarint(data[len(headerMagic):])
+		if n < 0 {
+			return n
+		}
+		return len(headerMagic) + n
+	})
+	if err != nil {
+		return debugs.WarpError(err, "read header error")
+	}
+	version, _, err := ParseHeader(rr.buf[rr.pos : rr.pos+headerLen])
+	if err != nil {
+		return debugs.WarpError(err, "ParseHeader error")
+	}
+	rr.pos += headerLen
+	rr.version = version
+	var (
+		golangType uint64
+		num        protowire.Number
+		typ        protowire.Type
+	)
+	headLen, err := rr.peek(func(data []byte) (n int) {
+		golangType, num, typ, n = readHead(data, rr.version)
+		return
+	})
+	if err != nil {
+		return debugs.WarpError(err, "read array start error")
+	}
+	if typ != protowire.StartGroupType || golangType != 0 {
+		return fmt.Errorf("[%s]not a array, type=%d, data type=%d", debugs.SourceCodeLoc(1), typ, golangType)
+	}
+	rr.pos += headLen
+	rr.tag = int(num)
+	rr.started = true
+	return nil
+}
+
+// peek return the length of next item at buf[pos:], parse return the length or a protowire error code,
+// more data is read if the item is truncated
+func (rr *RowReader) peek(parse func(data []byte) int) (int, error) {
+	for {
+		n := parse(rr.buf[rr.pos:])
+		if n >= 0 {
+			return n, nil
+		}
+		if err := protowire.ParseError(n); err != io.ErrUnexpectedEOF {
+			return 0, fmt.Errorf("[%s]parse error, err=%w", debugs.SourceCodeLoc(1), err)
+		}
+		if err := rr.fill(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// fill read more data to buffer, the consumed data is dropped
+func (rr *RowReader) fill() error {
+	if rr.eof {
+		return fmt.Errorf("[%s]data is truncated, err=%w", debugs.SourceCodeLoc(1), io.ErrUnexpectedEOF)
+	}
+	if rr.pos > 0 {
+		n := copy(rr.buf, rr.buf[rr.pos:])
+		rr.buf = rr.buf[:n]
+		rr.pos = 0
+	}
+	if len(rr.buf) == cap(rr.buf) {
+		if len(rr.buf) >= rr.opts.MaxRowSize {
+			return fmt.Errorf("[%s]row is larger than %d bytes", debugs.SourceCodeLoc(1), rr.opts.MaxRowSize)
+		}
+		newCap := 2 * cap(rr.buf)
+		if newCap > rr.opts.MaxRowSize {
+			newCap = rr.opts.MaxRowSize
+		}
+		newBuf := make([]byte, len(rr.buf), newCap)
+		copy(newBuf, rr.buf)
+		rr.buf = newBuf
+	}
+	for {
+		n, err := rr.r.Read(rr.buf[len(rr.buf):cap(rr.buf)])
+		rr.buf = rr.buf[:len(rr.buf)+n]
+		if err == io.EOF {
+			rr.eof = true
+			if n == 0 {
+				return fmt.Errorf("[%s]data is truncated, err=%w", debugs.SourceCodeLoc(1), io.ErrUnexpectedEOF)
+			}
+			return nil
+		}
+		if err != nil {
+			return debugs.WarpError(err, "read error")
+		}
+		if n > 0 {
+			return nil
+		}
+	}
+}
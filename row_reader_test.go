@@ -0,0 +1,118 @@
+package serializer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func readAllRows(r io.Reader) ([][]interface{}, error) {
+	rr := NewRowReader(r, RowReaderOptions{BufferSize: 8})
+	var rows [][]interface{}
+	for {
+		_, cols, err := rr.ReadRow()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, cols)
+	}
+}
+
+func TestRowReader(t *testing.T) {
+	rows := getTestRows()
+	v1, err := Encode(nil, 1, rows)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	v2, err := EncodeVersion(nil, 1, rows, FormatV2)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	withChecksum := AppendArrayStart(nil, 1)
+	for idx, row := range rows {
+		withChecksum, _ = Encode(withChecksum, idx+1, row)
+	}
+	withChecksum = AppendArrayEndWithChecksum(withChecksum, 1, 0)
+	v2WithChecksum, _, err := convertValue(AppendHeader(nil, FormatV2), withChecksum, FormatV1, FormatV2)
+	if err != nil {
+		t.Errorf("convert error, err=%+v", err)
+		return
+	}
+	for idx, buf := range [][]byte{v1, v2, withChecksum, v2WithChecksum} {
+		got, err := readAllRows(iotest.OneByteReader(bytes.NewReader(buf)))
+		if err != nil {
+			t.Errorf("case %d read error, err=%+v", idx, err)
+			continue
+		}
+		if !reflect.DeepEqual(rows, got) {
+			t.Errorf("case %d not equal, %+v", idx, got)
+		}
+	}
+	// the buffer is reused, but the values still valid
+	got, err := readAllRows(bytes.NewReader(v1))
+	if err != nil || !reflect.DeepEqual(rows, got) {
+		t.Errorf("read error, rows=%+v, err=%+v", got, err)
+	}
+}
+
+func TestRowReaderError(t *testing.T) {
+	rows := getTestRows()
+	buf, _ := Encode(nil, 1, rows)
+	if _, err := readAllRows(bytes.NewReader(buf[:len(buf)-1])); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("should be io.ErrUnexpectedEOF, err=%+v", err)
+	}
+	withChecksum := AppendArrayStart(nil, 1)
+	withChecksum, _ = Encode(withChecksum, 1, rows[0])
+	withChecksum = AppendArrayEndWithChecksum(withChecksum, 1, 0)
+	withChecksum[5] ^= 0x01
+	if _, err := readAllRows(bytes.NewReader(withChecksum)); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("should be ErrChecksumMismatch, err=%+v", err)
+	}
+	rr := NewRowReader(bytes.NewReader(buf), RowReaderOptions{BufferSize: 12, MaxRowSize: 16})
+	if _, _, err := rr.ReadRow(); err == nil {
+		t.Errorf("row larger than MaxRowSize should fail")
+	}
+	if cap(rr.buf) > 16 {
+		t.Errorf("buffer grows to %d bytes, more than MaxRowSize", cap(rr.buf))
+	}
+}
+
+func TestRowWriterToRowReader(t *testing.T) {
+	out := &bytes.Buffer{}
+	rw := NewRowWriter(out, RowWriterOptions{FlushThreshold: 16})
+	rr := NewRowReader(out, RowReaderOptions{})
+	for i := 0; i < 10; i++ {
+		if err := rw.WriteRow(i, "abc"); err != nil {
+			t.Errorf("write error, err=%+v", err)
+			return
+		}
+		if err := rw.Flush(); err != nil {
+			t.Errorf("flush error, err=%+v", err)
+			return
+		}
+		// each row can be read after it is flushed
+		tag, cols, err := rr.ReadRow()
+		if err != nil {
+			t.Errorf("read error, err=%+v", err)
+			return
+		}
+		if tag != i+1 || !reflect.DeepEqual([]interface{}{i, "abc"}, cols) {
+			t.Errorf("row %d not equal, tag=%d, cols=%+v", i, tag, cols)
+		}
+	}
+	if err := rw.Close(); err != nil {
+		t.Errorf("close error, err=%+v", err)
+		return
+	}
+	if _, _, err := rr.ReadRow(); err != io.EOF {
+		t.Errorf("should be io.EOF, err=%+v", err)
+	}
+}
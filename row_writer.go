@@ -0,0 +1,112 @@
+package serializer
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+const (
+	defaultRowWriterBufferSize     = 4 * 1024
+	defaultRowWriterFlushThreshold = 64 * 1024
+)
+
+// RowWriterOptions config of RowWriter, zero value means default
+type RowWriterOptions struct {
+	Tag            int // tag of the outer array, default is 1
+	BufferSize     int // initial capacity of the buffer, default is 4KB
+	FlushThreshold int // write the buffer to io.Writer when it reaches the size, default is 64KB
+}
+
+// RowWriter write rows to io.Writer streamly, the output is same as Encode with a [][]interface{}
+type RowWriter struct {
+	w       io.Writer
+	opts    RowWriterOptions
+	buf     []byte
+	rows    int
+	err     error // the first write error, RowWriter can not be used after it
+	written int64
+	closed  bool
+}
+
+// NewRowWriter create a RowWriter, the array header is written with the first flush
+func NewRowWriter(w io.Writer, opts RowWriterOptions) *RowWriter {
+	if opts.Tag <= 0 {
+		opts.Tag = 1
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultRowWriterBufferSize
+	}
+	if opts.FlushThreshold <= 0 {
+		opts.FlushThreshold = defaultRowWriterFlushThreshold
+	}
+	rw := &RowWriter{
+		w:    w,
+		opts: opts,
+		buf:  make([]byte, 0, opts.BufferSize),
+	}
+	rw.buf = AppendArrayStart(rw.buf, opts.Tag)
+	return rw
+}
+
+// WriteRow encode a row, tag of the row is its index + 1.
+// a row failed to encode is not written, and the RowWriter can still be used.
+func (rw *RowWriter) WriteRow(cols ...interface{}) error {
+	if rw.err != nil {
+		return rw.err
+	}
+	if rw.closed {
+		return fmt.Errorf("[%s]RowWriter is closed", debugs.SourceCodeLoc(1))
+	}
+	start := len(rw.buf)
+	buf, err := Encode(rw.buf, rw.rows+1, cols)
+	if err != nil {
+		rw.buf = buf[:start]
+		return debugs.WarpError(err, fmt.Sprintf("encode row %d error", rw.rows))
+	}
+	rw.buf = buf
+	rw.rows++
+	if len(rw.buf) >= rw.opts.FlushThreshold {
+		return rw.Flush()
+	}
+	return nil
+}
+
+// Flush write the buffered data to io.Writer
+func (rw *RowWriter) Flush() error {
+	if rw.err != nil {
+		return rw.err
+	}
+	if len(rw.buf) == 0 {
+		return nil
+	}
+	n, err := rw.w.Write(rw.buf)
+	rw.written += int64(n)
+	if err != nil {
+		rw.err = debugs.WarpError(err, "write error")
+		return rw.err
+	}
+	rw.buf = rw.buf[:0]
+	return nil
+}
+
+// Close write the array end flag and flush, the io.Writer is not closed
+func (rw *RowWriter) Close() error {
+	if rw.closed {
+		return rw.err
+	}
+	rw.closed = true
+	rw.buf = AppendArrayEnd(rw.buf, rw.opts.Tag)
+	return rw.Flush()
+}
+
+// Rows return count of rows written
+func (rw *RowWriter) Rows() int {
+	return rw.rows
+}
+
+// Written return count of bytes written to io.Writer
+func (rw *RowWriter) Written() int64 {
+	return rw.written
+}
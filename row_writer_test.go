@@ -0,0 +1,53 @@
+package serializer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type countWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestRowWriter(t *testing.T) {
+	rows := getTestRows()
+	out := &countWriter{}
+	rw := NewRowWriter(out, RowWriterOptions{FlushThreshold: 100})
+	for _, row := range rows {
+		if err := rw.WriteRow(row...); err != nil {
+			t.Errorf("write error, err=%+v", err)
+			return
+		}
+	}
+	if err := rw.WriteRow(func() {}); err == nil {
+		t.Errorf("write not supported type should fail")
+	}
+	if err := rw.Close(); err != nil {
+		t.Errorf("close error, err=%+v", err)
+		return
+	}
+	if out.writes < 2 {
+		t.Errorf("should flush by threshold, writes=%d", out.writes)
+	}
+	if rw.Rows() != len(rows) || rw.Written() != int64(out.Len()) {
+		t.Errorf("rows=%d, written=%d", rw.Rows(), rw.Written())
+	}
+	expected, err := Encode(nil, 1, rows)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual(expected, out.Bytes()) {
+		t.Errorf("not same as Encode")
+	}
+	if err = rw.WriteRow(1); err == nil {
+		t.Errorf("write after close should fail")
+	}
+}
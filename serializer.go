@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"time"
 
 	"google.golang.org/protobuf/encoding/protowire"
 
@@ -36,10 +37,50 @@ const (
 	tString
 	tBytes
 	tJSON
+	tMap // map[string]interface{}, a group of key/value pairs
+	// zigzag encoded signed integers, Encode use them for all signed types,
+	// tInt8/tInt16/tInt32/tInt64/tInt are still readable for old buffers
+	tSint8
+	tSint16
+	tSint32
+	tSint64
+	tSint
+	// packed numeric slices, one length-delimited field of back to back values
+	tInt8Slice
+	tInt16Slice
+	tUint16Slice
+	tInt32Slice
+	tUint32Slice
+	tInt64Slice
+	tUint64Slice
+	tIntSlice
+	tFloat32Slice
+	tFloat64Slice
+	tColumns     // column-major rows, see EncodeColumns
+	tChecksum    // CRC32C trailer of array, see EncodeWithChecksum
+	tCompressed  // compression frame, see CompressArray
+	tIndex       // row offsets trailer of array, see AppendIndexTrailer
+	tTime        // time.Time, see appendTime
+	tDuration    // time.Duration, zigzag varint of nanoseconds
+	tNull        // nil interface or nil pointer, the varint is the data type of pointer, see appendNull
+	tStringSlice // []string, a group of repeated bytes fields
+	tBytesSlice  // [][]byte, a group of repeated bytes fields
+	tBoolSlice   // []bool, packed
+	tAnyMap      // map with non-string keys, a group of key/value pairs with their own data types
+
+	tBuiltinEnd // not a type, the built-in types are less than it, others are decoded to RawValue in lenient mode
+)
+
+const (
+	tagOfMapKey   = 1
+	tagOfMapValue = 2
 )
 
 // Encode encode []interface{} to binary
 func Encode(buf []byte, tag int, v interface{}) ([]byte, error) {
+	if typeID, ok := isNil(v); ok {
+		return appendNull(buf, tag, typeID), nil
+	}
 	switch v1 := v.(type) {
 	case bool:
 		buf = setType(buf, tBool)
@@ -50,13 +91,13 @@ func Encode(buf []byte, tag int, v interface{}) ([]byte, error) {
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
 		buf = protowire.AppendVarint(buf, protowire.EncodeBool(*v1))
 	case int8:
-		buf = setType(buf, tInt8)
+		buf = setType(buf, tSint8)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
-		buf = protowire.AppendVarint(buf, uint64(v1))
+		buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(v1)))
 	case *int8:
-		buf = setType(buf, tInt8)
+		buf = setType(buf, tSint8)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
-		buf = protowire.AppendVarint(buf, uint64(*v1))
+		buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(*v1)))
 	case uint8:
 		buf = setType(buf, tUint8)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
@@ -66,13 +107,13 @@ func Encode(buf []byte, tag int, v interface{}) ([]byte, error) {
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
 		buf = protowire.AppendVarint(buf, uint64(*v1))
 	case int16:
-		buf = setType(buf, tInt16)
+		buf = setType(buf, tSint16)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
-		buf = protowire.AppendVarint(buf, uint64(v1))
+		buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(v1)))
 	case *int16:
-		buf = setType(buf, tInt16)
+		buf = setType(buf, tSint16)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
-		buf = protowire.AppendVarint(buf, uint64(*v1))
+		buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(*v1)))
 	case uint16:
 		buf = setType(buf, tUint16)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
@@ -82,13 +123,13 @@ func Encode(buf []byte, tag int, v interface{}) ([]byte, error) {
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
 		buf = protowire.AppendVarint(buf, uint64(*v1))
 	case int32:
-		buf = setType(buf, tInt32)
+		buf = setType(buf, tSint32)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
-		buf = protowire.AppendVarint(buf, uint64(v1))
+		buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(v1)))
 	case *int32:
-		buf = setType(buf, tInt32)
+		buf = setType(buf, tSint32)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
-		buf = protowire.AppendVarint(buf, uint64(*v1))
+		buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(*v1)))
 	case uint32:
 		buf = setType(buf, tUint32)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
@@ -98,13 +139,13 @@ func Encode(buf []byte, tag int, v interface{}) ([]byte, error) {
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
 		buf = protowire.AppendVarint(buf, uint64(*v1))
 	case int64:
-		buf = setType(buf, tInt64)
+		buf = setType(buf, tSint64)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
-		buf = protowire.AppendVarint(buf, uint64(v1))
+		buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(v1))
 	case *int64:
-		buf = setType(buf, tInt64)
+		buf = setType(buf, tSint64)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
-		buf = protowire.AppendVarint(buf, uint64(*v1))
+		buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(*v1))
 	case uint64:
 		buf = setType(buf, tUint64)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
@@ -114,13 +155,13 @@ func Encode(buf []byte, tag int, v interface{}) ([]byte, error) {
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
 		buf = protowire.AppendVarint(buf, *v1)
 	case int:
-		buf = setType(buf, tInt)
+		buf = setType(buf, tSint)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
-		buf = protowire.AppendVarint(buf, uint64(v1))
+		buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(v1)))
 	case *int:
-		buf = setType(buf, tInt)
+		buf = setType(buf, tSint)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
-		buf = protowire.AppendVarint(buf, uint64(*v1))
+		buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(*v1)))
 	case float32:
 		buf = setType(buf, tFloat32)
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.Fixed32Type)
@@ -163,7 +204,55 @@ func Encode(buf []byte, tag int, v interface{}) ([]byte, error) {
 			}
 		}
 		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.EndGroupType)
+	case [][]interface{}:
+		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.StartGroupType)
+		var err error
+		for idx, item := range v1 {
+			buf, err = Encode(buf, idx+1, item)
+			if err != nil {
+				return buf, debugs.WarpError(err, fmt.Sprintf("encode row %d error", idx))
+			}
+		}
+		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.EndGroupType)
+	case map[string]interface{}:
+		return encodeMap(buf, tag, v1)
+	case []int8, []int16, []uint16, []int32, []uint32, []int64, []uint64, []int, []float32, []float64, []bool:
+		buf = encodePacked(buf, tag, v1)
+	case []string:
+		buf = encodeStrings(buf, tag, v1)
+	case [][]byte:
+		buf = encodeBytesSlice(buf, tag, v1)
+	case time.Time:
+		buf = appendTime(buf, tag, v1)
+	case *time.Time:
+		buf = appendTime(buf, tag, *v1)
+	case time.Duration:
+		buf = setType(buf, tDuration)
+		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
+		buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(v1)))
+	case *time.Duration:
+		buf = setType(buf, tDuration)
+		buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.VarintType)
+		buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(*v1)))
+	case RawMessage:
+		return encodeRawMessage(buf, tag, v1)
+	case RawValue:
+		return encodeRawValue(buf, tag, &v1)
+	case *RawValue:
+		return encodeRawValue(buf, tag, v1)
 	default:
+		if out, ok, err := encodeCustom(buf, tag, v); ok {
+			return out, err
+		}
+		if rv, ok := isStruct(v); ok {
+			return encodeStruct(buf, tag, rv)
+		}
+		if rv, ok := isStructSlice(v); ok {
+			return encodeStructSlice(buf, tag, rv)
+		}
+		if rv, ok := isMap(v); ok {
+			return encodeReflectMap(buf, tag, rv)
+		}
 		// try to use json encode
 		temp, err := json.Marshal(v)
 		if err != nil {
@@ -178,27 +267,63 @@ func Encode(buf []byte, tag int, v interface{}) ([]byte, error) {
 }
 
 // Decode decode binary to []interface{}
+// the format version is detected by the header, a buffer without header is V1
 func Decode(buf []byte) ([]byte, interface{}, error) {
-	tag, typeOfField, totalLen := protowire.ConsumeField(buf)
-	if totalLen < 0 {
-		return buf, nil, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), totalLen)
+	d := decoder{}
+	return d.decodeBuffer(buf)
+}
+
+// decodeBuffer decode a value with optional header
+func (d *decoder) decodeBuffer(buf []byte) ([]byte, interface{}, error) {
+	version, body, err := ParseHeader(buf)
+	if err != nil {
+		return buf, nil, debugs.WarpError(err, "ParseHeader error")
 	}
-	golangType := uint64(0)
-	if tag == tagOfDataType && typeOfField == protowire.VarintType {
-		buf = buf[totalLen-1:]
-		var headLen int
-		golangType, headLen = protowire.ConsumeVarint(buf)
-		if headLen < 0 {
-			return buf, nil, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), headLen)
-		}
-		buf = buf[headLen:]
-		tag, typeOfField, totalLen = protowire.ConsumeField(buf)
-		if totalLen < 0 {
-			return buf, nil, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), totalLen)
-		}
+	if version == FormatV1 {
+		return d.decode(body, 0)
+	}
+	if err = d.checkDepth(body, version); err != nil {
+		return buf, nil, err
+	}
+	temp, n, err := convertValue(nil, body, version, FormatV1)
+	if err != nil {
+		return buf, nil, debugs.WarpError(err, "convert to V1 error")
+	}
+	_, value, err := d.decode(temp, 0)
+	return body[n:], value, err
+}
+
+// decode decode a value without limits, golangType is used if the value has no data type field
+func decode(buf []byte, golangType uint64) ([]byte, interface{}, error) {
+	d := decoder{}
+	return d.decode(buf, golangType)
+}
+
+// decode decode a value, golangType is used if the value has no data type field
+func (d *decoder) decode(buf []byte, golangType uint64) ([]byte, interface{}, error) {
+	item := buf
+	typeInData, tag, typeOfField, headLen := consumeHead(buf)
+	if headLen < 0 {
+		return buf, nil, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), headLen)
+	}
+	if typeInData != 0 {
+		golangType = typeInData
+	} else if typeOfField == protowire.StartGroupType {
+		golangType = 0 // a group without data type field is an array
 	}
-	_, headLen := protowire.ConsumeVarint(buf)
 	buf = buf[headLen:]
+	valueLen := protowire.ConsumeFieldValue(tag, typeOfField, buf)
+	if valueLen < 0 {
+		return buf, nil, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+	}
+	if golangType >= MinUserTypeID {
+		if t := getCustomTypeByID(golangType); t != nil {
+			return d.decodeCustom(buf, t, tag, typeOfField)
+		}
+	}
+	if d.opts.KeepUnknownTypes && golangType >= tBuiltinEnd {
+		return d.decodeRawValue(buf, golangType, tag, typeOfField)
+	}
 	switch typeOfField {
 	case protowire.VarintType:
 		value, dataLen := protowire.ConsumeVarint(buf)
@@ -206,6 +331,9 @@ func Decode(buf []byte) ([]byte, interface{}, error) {
 			return buf, nil, fmt.Errorf("[%s]read VarintType error,code=%d", debugs.SourceCodeLoc(1), dataLen)
 		}
 		buf = buf[dataLen:]
+		if golangType == tNull {
+			return buf, d.null(value), nil
+		}
 		v, err := uint64ToInterfaceType(value, golangType)
 		if err != nil {
 			return buf, v, debugs.WarpError(err, "uint64ToInterfaceType error")
@@ -236,57 +364,150 @@ func Decode(buf []byte) ([]byte, interface{}, error) {
 	case protowire.BytesType:
 		switch golangType {
 		case tString:
-			value, dataLen := protowire.ConsumeString(buf)
+			value, dataLen := protowire.ConsumeBytes(buf)
 			if dataLen < 0 {
 				return buf, nil, fmt.Errorf("[%s]read BytesType error,code=%d", debugs.SourceCodeLoc(1), dataLen)
 			}
+			if err := d.checkString(len(value)); err != nil {
+				return buf, nil, err
+			}
 			buf = buf[dataLen:]
-			return buf, value, nil
+			return buf, string(value), nil
 		case tBytes:
 			value, dataLen := protowire.ConsumeBytes(buf)
 			if dataLen < 0 {
 				return buf, nil, fmt.Errorf("[%s]read BytesType error,code=%d", debugs.SourceCodeLoc(1), dataLen)
 			}
+			if err := d.checkString(len(value)); err != nil {
+				return buf, nil, err
+			}
 			buf = buf[dataLen:]
 			return buf, value, nil
+		case tCompressed:
+			value, dataLen := protowire.ConsumeBytes(buf)
+			if dataLen < 0 {
+				return buf, nil, fmt.Errorf("[%s]read BytesType error,code=%d", debugs.SourceCodeLoc(1), dataLen)
+			}
+			buf = buf[dataLen:]
+			raw, err := d.decompressFrame(value)
+			if err != nil {
+				return buf, nil, debugs.WarpError(err, "decompressFrame error")
+			}
+			_, out, err := d.decodeBuffer(raw)
+			if err != nil {
+				return buf, nil, debugs.WarpError(err, "decode compressed data error")
+			}
+			return buf, out, nil
+		case tTime:
+			value, dataLen := protowire.ConsumeBytes(buf)
+			if dataLen < 0 {
+				return buf, nil, fmt.Errorf("[%s]read BytesType error,code=%d", debugs.SourceCodeLoc(1), dataLen)
+			}
+			buf = buf[dataLen:]
+			t, err := decodeTime(value)
+			if err != nil {
+				return buf, nil, debugs.WarpError(err, "decodeTime error")
+			}
+			return buf, t, nil
 		case tJSON:
 			value, dataLen := protowire.ConsumeBytes(buf)
 			if dataLen < 0 {
 				return buf, nil, fmt.Errorf("[%s]read BytesType error,code=%d", debugs.SourceCodeLoc(1), dataLen)
 			}
+			if err := d.checkString(len(value)); err != nil {
+				return buf, nil, err
+			}
 			buf = buf[dataLen:]
-			decoder := json.NewDecoder(bytes.NewBuffer(value))
-			decoder.UseNumber()
+			jsonDecoder := json.NewDecoder(bytes.NewBuffer(value))
+			jsonDecoder.UseNumber()
 			var out interface{}
-			if err := decoder.Decode(&out); err != nil {
+			if err := jsonDecoder.Decode(&out); err != nil {
 				return buf, nil, fmt.Errorf("[%s]decode json error,err=%s", debugs.SourceCodeLoc(1), err.Error())
 			}
 			return buf, out, nil
+		case tInt8Slice, tInt16Slice, tUint16Slice, tInt32Slice, tUint32Slice, tInt64Slice, tUint64Slice, tIntSlice,
+			tFloat32Slice, tFloat64Slice, tBoolSlice:
+			value, dataLen := protowire.ConsumeBytes(buf)
+			if dataLen < 0 {
+				return buf, nil, fmt.Errorf("[%s]read BytesType error,code=%d", debugs.SourceCodeLoc(1), dataLen)
+			}
+			buf = buf[dataLen:]
+			if err := d.checkPacked(value, golangType); err != nil {
+				return buf, nil, err
+			}
+			out, err := decodePacked(value, golangType)
+			if err != nil {
+				return buf, nil, debugs.WarpError(err, "decodePacked error")
+			}
+			return buf, out, nil
 		default:
 			return buf, nil, fmt.Errorf("[%s]read BytesType error,golangType=%d", debugs.SourceCodeLoc(1), golangType)
 		}
 	case protowire.StartGroupType:
-		out := make([]interface{}, 0, defaultArrayCount)
-		for len(buf) > 0 {
-			leftData, value, err := Decode(buf)
+		if d.opts.RawMessages && d.depth > 0 && (golangType == 0 || golangType == tMap || golangType == tAnyMap) {
+			return buf[valueLen:], RawMessage(item[:headLen+valueLen]), nil
+		}
+		if err := d.enter(); err != nil {
+			return buf, nil, err
+		}
+		defer d.leave()
+		switch golangType {
+		case tMap:
+			return d.decodeMap(buf)
+		case tAnyMap:
+			return d.decodeAnyMap(buf)
+		case tColumns:
+			return d.decodeColumns(buf)
+		case tStringSlice, tBytesSlice:
+			return d.decodeRepeated(buf, golangType)
+		}
+		leftData, items, err := d.decodeItems(buf, 0)
+		return leftData, items, err
+	default:
+		return buf, nil, fmt.Errorf("[%s]unknown field tag=%d", debugs.SourceCodeLoc(1), typeOfField)
+	}
+}
+
+// decodeItems decode array items after the group start tag, itemType is used if item has no data type field
+func (d *decoder) decodeItems(buf []byte, itemType uint64) ([]byte, []interface{}, error) {
+	out := make([]interface{}, 0, defaultArrayCount)
+	body := buf
+	for len(buf) > 0 {
+		nextDataType, _, nextType, nextHeadLen := consumeHead(buf)
+		if nextHeadLen < 0 {
+			return buf, out, fmt.Errorf("[%s]decode array item end flag error,code=%d", debugs.SourceCodeLoc(1), nextHeadLen)
+		}
+		if nextType == protowire.EndGroupType {
+			buf = buf[nextHeadLen:]
+			return buf, out, nil
+		}
+		if nextDataType == tChecksum {
+			n, err := consumeChecksum(body[:len(body)-len(buf)], buf)
 			if err != nil {
-				return buf, out, debugs.WarpError(err, "decode array item error")
-			}
-			buf = leftData
-			out = append(out, value)
-			_, nextType, nextHeadLen := protowire.ConsumeTag(buf)
-			if nextHeadLen < 0 {
-				return buf, out, debugs.WarpError(err, "decode array item end flag error")
+				return buf, out, debugs.WarpError(err, "check array checksum error")
 			}
-			if nextType == protowire.EndGroupType {
-				buf = buf[headLen:]
-				return buf, out, nil
+			buf = buf[n:]
+			continue
+		}
+		if nextDataType == tIndex {
+			leftData, err := skipValue(buf)
+			if err != nil {
+				return buf, out, debugs.WarpError(err, "skip array index error")
 			}
+			buf = leftData
+			continue
 		}
-		return buf, out, nil
-	default:
-		return buf, nil, fmt.Errorf("[%s]unknown field tag=%d", debugs.SourceCodeLoc(1), typeOfField)
+		if err := d.addElements(1, sizeOfInterface); err != nil {
+			return buf, out, err
+		}
+		leftData, value, err := d.decode(buf, itemType)
+		if err != nil {
+			return buf, out, debugs.WarpError(err, "decode array item error")
+		}
+		buf = leftData
+		out = append(out, value)
 	}
+	return buf, out, nil
 }
 
 // AppendArrayStart add the array header to buffer, for serialize data streamly
@@ -311,6 +532,10 @@ func ReadArray(buf []byte) (arrayData []byte, headLen int, leftData []byte, tag
 		return
 	}
 	_, headLen = protowire.ConsumeVarint(buf)
+	if err = verifyArray(buf[headLen:totalLen-protowire.SizeTag(arrTag)], arrTag); err != nil {
+		err = debugs.WarpError(err, "verifyArray error")
+		return
+	}
 	arrayData = buf[:totalLen]
 	leftData = buf[totalLen:]
 	tag = int(arrTag)
@@ -322,41 +547,32 @@ type RowCallback func(tag int, cols ...interface{}) error
 
 // ReadEachRow read rows, send data to callback func
 func ReadEachRow(buf []byte, callback RowCallback) error {
-	arrayData, headLen, leftData, tag, err := ReadArray(buf)
-	if err != nil {
-		return debugs.WarpError(err, "ReadArray error")
-	}
-	arrayData = arrayData[headLen:]
-	for len(arrayData) > 2 {
-		arrayData, _, leftData, tag, err = ReadArray(arrayData)
-		if err != nil {
-			return debugs.WarpError(err, "ReadArray read row error")
+	it := NewRowIterator(buf)
+	for it.Next() {
+		tag, cols := it.Row()
+		if err := callback(tag, cols...); err != nil {
+			return debugs.WarpError(err, "callback error")
 		}
-		var values interface{}
-		_, values, err = Decode(arrayData)
-		if err != nil {
-			return debugs.WarpError(err, "Decode row error")
-		}
-		arrayData = leftData
-		//
-		cols, ok := values.([]interface{})
-		if !ok {
-			if err = callback(tag, values); err != nil {
-				return debugs.WarpError(err, "callback with one value error")
-			}
-		} else {
-			if err = callback(tag, cols...); err != nil {
-				return debugs.WarpError(err, "callback with multi value error")
-			}
+	}
+	return it.Err()
+}
+
+// ReadEachRowWithOptions read rows like ReadEachRow, the limits of opts apply to the compression frame and each row
+func ReadEachRowWithOptions(buf []byte, opts DecodeOptions, callback RowCallback) error {
+	it := newRowIterator(buf, opts)
+	for it.Next() {
+		tag, cols := it.Row()
+		if err := callback(tag, cols...); err != nil {
+			return debugs.WarpError(err, "callback error")
 		}
 	}
-	return nil
+	return it.Err()
 }
 
 func setType(buf []byte, t uint64) []byte {
 	buf = protowire.AppendTag(buf, protowire.Number(tagOfDataType), protowire.VarintType)
 	buf = protowire.AppendVarint(buf, t)
-	// buf = protowire.AppendTag(buf, protowire.Number(t), protowire.VarintType) // use this way to reduce 1 byte, but read become complex
+	// FormatV2 fold the data type into the tag to reduce 1 byte, see version.go
 	return buf
 }
 
@@ -389,6 +605,18 @@ func uint64ToInterfaceType(v uint64, golangType uint64) (interface{}, error) {
 		return v, nil
 	case tInt:
 		return int(v), nil
+	case tSint8:
+		return int8(protowire.DecodeZigZag(v)), nil
+	case tSint16:
+		return int16(protowire.DecodeZigZag(v)), nil
+	case tSint32:
+		return int32(protowire.DecodeZigZag(v)), nil
+	case tSint64:
+		return protowire.DecodeZigZag(v), nil
+	case tSint:
+		return int(protowire.DecodeZigZag(v)), nil
+	case tDuration:
+		return time.Duration(protowire.DecodeZigZag(v)), nil
 	case tFloat32:
 		return math.Float32frombits(uint32(v)), nil
 	case tFloat64:
@@ -427,6 +655,10 @@ func BasicTypeToString(v interface{}) (string, error) {
 		return fmt.Sprintf("%f", r), nil
 	case string:
 		return r, nil
+	case time.Time:
+		return r.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return r.String(), nil
 	default:
 		return "", fmt.Errorf("[%s]not support type %T", debugs.SourceCodeLoc(1), v)
 	}
@@ -3,9 +3,12 @@ package serializer
 import (
 	"fmt"
 	"log"
+	"math"
 	"reflect"
 	"testing"
 
+	"google.golang.org/protobuf/encoding/protowire"
+
 	stringutil "github.com/ahfuzhang/serializer/util/strings"
 )
 
@@ -52,10 +55,10 @@ func TestEncodeDecode(t *testing.T) {
 	}
 	t.Logf("leftdata len=%d\n", len(leftData))
 	t.Logf("\t type=%T, values = %+v\n", values, values)
-	// if !reflect.DeepEqual(arr, values) {
-	// 	t.Errorf("not equal")
-	// 	return
-	// }
+	if !reflect.DeepEqual(arr, values) {
+		t.Errorf("not equal")
+		return
+	}
 }
 
 func TestSreamlyRead(t *testing.T) {
@@ -167,3 +170,41 @@ func TestAppendArrayStart(t *testing.T) {
 		return
 	}
 }
+
+func TestZigZag(t *testing.T) {
+	arr := []interface{}{int8(-22), int16(-24 * 128), int32(-99), int64(-18), int(-19), int64(math.MinInt64)}
+	buf, err := Encode(nil, 1, arr)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	// group start/end: 2 bytes, type and tag: 3 bytes for each item, values: 1+2+2+1+1+10 bytes
+	if len(buf) != 2+len(arr)*3+17 {
+		t.Errorf("zigzag size not match, len=%d", len(buf))
+		fmt.Println(stringutil.HexFormat(buf))
+	}
+	_, values, err := Decode(buf)
+	if err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual(arr, values) {
+		t.Errorf("not equal, %+v", values)
+		return
+	}
+	// buffers written with the old signed type ids are still readable
+	v1, v2 := int8(-22), int64(-18)
+	old := []byte{0x0b, 0x78, tInt8, 0x08}
+	old = protowire.AppendVarint(old, uint64(v1))
+	old = append(old, 0x78, tInt64, 0x10)
+	old = protowire.AppendVarint(old, uint64(v2))
+	old = append(old, 0x0c)
+	_, values, err = Decode(old)
+	if err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual([]interface{}{int8(-22), int64(-18)}, values) {
+		t.Errorf("not equal, %+v", values)
+	}
+}
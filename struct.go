@@ -0,0 +1,357 @@
+package serializer
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+const tagName = "serializer"
+
+type structField struct {
+	index int          // index in struct
+	num   int          // field number in binary
+	name  string       // for error message
+	basic reflect.Type // not nil if field is a named basic type, convert to it before encode
+}
+
+type structInfo struct {
+	fields []structField
+	byNum  map[int]int // field number -> index of fields
+}
+
+var (
+	structInfoCache sync.Map // reflect.Type -> *structInfo
+	jsonMarshaler   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// basic types which Encode can encode directly
+var basicTypes = map[reflect.Kind]reflect.Type{
+	reflect.Bool:    reflect.TypeOf(false),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Int16:   reflect.TypeOf(int16(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Int:     reflect.TypeOf(int(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+	reflect.String:  reflect.TypeOf(""),
+}
+
+// getStructInfo get field numbers of a struct type, the result is cached.
+// field number is from tag `serializer:"N"`, or index of field + 1 if no tag.
+// use `serializer:"-"` to skip a field, unexported fields are skipped too.
+func getStructInfo(t reflect.Type) (*structInfo, error) {
+	if info, ok := structInfoCache.Load(t); ok {
+		return info.(*structInfo), nil
+	}
+	info := &structInfo{
+		fields: make([]structField, 0, t.NumField()),
+		byNum:  make(map[int]int, t.NumField()),
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		num := i + 1
+		if tag, ok := f.Tag.Lookup(tagName); ok {
+			tag = strings.TrimSpace(tag)
+			if tag == "-" {
+				continue
+			}
+			n, err := strconv.Atoi(tag)
+			if err != nil || n <= 0 || n > int(protowire.MaxValidNumber) {
+				return nil, fmt.Errorf("[%s]invalid field number of %s.%s, tag=%s", debugs.SourceCodeLoc(1), t.Name(), f.Name, tag)
+			}
+			num = n
+		}
+		if j, ok := info.byNum[num]; ok {
+			return nil, fmt.Errorf("[%s]duplicate field number %d of %s.%s and %s.%s",
+				debugs.SourceCodeLoc(1), num, t.Name(), info.fields[j].name, t.Name(), f.Name)
+		}
+		field := structField{index: i, num: num, name: f.Name}
+		if basic, ok := basicTypes[f.Type.Kind()]; ok && f.Type != basic && f.Type != durationType {
+			field.basic = basic
+		}
+		info.byNum[num] = len(info.fields)
+		info.fields = append(info.fields, field)
+	}
+	actual, _ := structInfoCache.LoadOrStore(t, info)
+	return actual.(*structInfo), nil
+}
+
+// isStruct check if v is a struct or a pointer to struct, which should be encoded by encodeStruct
+func isStruct(v interface{}) (reflect.Value, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	return rv, rv.Kind() == reflect.Struct && isPlainType(rv.Type())
+}
+
+// isStructSlice check if v is a slice of struct(or pointer to struct), which should be encoded by encodeStructSlice
+func isStructSlice(v interface{}) (reflect.Value, bool) {
+	rv := reflect.ValueOf(v)
+	return rv, rv.Kind() == reflect.Slice && isStructSliceType(rv.Type())
+}
+
+// isStructSliceType check if elements of slice type t are struct, pointer to struct, or such slices
+func isStructSliceType(t reflect.Type) bool {
+	elem := t.Elem()
+	switch elem.Kind() {
+	case reflect.Ptr:
+		elem = elem.Elem()
+	case reflect.Slice:
+		return isStructSliceType(elem)
+	}
+	return elem.Kind() == reflect.Struct && isPlainType(elem)
+}
+
+// isPlainType check if a struct or map type has no custom JSON format
+func isPlainType(t reflect.Type) bool {
+	return !t.Implements(jsonMarshaler) && !reflect.PtrTo(t).Implements(jsonMarshaler)
+}
+
+// encodeStruct encode struct as a group, each field use its field number as tag
+func encodeStruct(buf []byte, tag int, rv reflect.Value) ([]byte, error) {
+	info, err := getStructInfo(rv.Type())
+	if err != nil {
+		return buf, debugs.WarpError(err, "getStructInfo error")
+	}
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.StartGroupType)
+	for i := range info.fields {
+		f := &info.fields[i]
+		fv := rv.Field(f.index)
+		switch fv.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			if fv.IsNil() {
+				continue // nil field is omitted
+			}
+		}
+		if f.basic != nil && getCustomType(fv.Type()) == nil {
+			fv = fv.Convert(f.basic)
+		}
+		buf, err = Encode(buf, f.num, fv.Interface())
+		if err != nil {
+			return buf, debugs.WarpError(err, fmt.Sprintf("encode field %s error", f.name))
+		}
+	}
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.EndGroupType)
+	return buf, nil
+}
+
+// encodeStructSlice encode a slice of struct as a group of struct groups, nil pointers are encoded as null
+func encodeStructSlice(buf []byte, tag int, rv reflect.Value) ([]byte, error) {
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.StartGroupType)
+	var err error
+	for i := 0; i < rv.Len(); i++ {
+		buf, err = Encode(buf, i+1, rv.Index(i).Interface())
+		if err != nil {
+			return buf, debugs.WarpError(err, fmt.Sprintf("encode item %d of %s error", i, rv.Type()))
+		}
+	}
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.EndGroupType)
+	return buf, nil
+}
+
+// DecodeInto decode binary into v, v must be a non-nil pointer.
+// struct fields are matched by field number, unknown fields are skipped.
+func DecodeInto(buf []byte, v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return buf, fmt.Errorf("[%s]DecodeInto need a non-nil pointer, %T", debugs.SourceCodeLoc(1), v)
+	}
+	version, body, err := ParseHeader(buf)
+	if err != nil {
+		return buf, debugs.WarpError(err, "ParseHeader error")
+	}
+	if version == FormatV1 {
+		return decodeInto(body, rv.Elem())
+	}
+	temp, n, err := convertValue(nil, body, version, FormatV1)
+	if err != nil {
+		return buf, debugs.WarpError(err, "convert to V1 error")
+	}
+	if _, err = decodeInto(temp, rv.Elem()); err != nil {
+		return buf, err
+	}
+	return body[n:], nil
+}
+
+func decodeInto(buf []byte, rv reflect.Value) ([]byte, error) {
+	if rv.Type() == rawMessageType {
+		leftData, err := skipValue(buf)
+		if err != nil {
+			return buf, debugs.WarpError(err, "skipValue error")
+		}
+		rv.SetBytes(buf[:len(buf)-len(leftData)])
+		return leftData, nil
+	}
+	if rv.Kind() != reflect.Ptr && rv.CanAddr() && rv.Addr().Type().Implements(unmarshalerType) {
+		if _, tag, typ, n := consumeHead(buf); n > 0 && typ == protowire.StartGroupType {
+			leftData, err := rv.Addr().Interface().(Unmarshaler).ConsumeSerializer(buf[n:])
+			if err != nil {
+				return buf, debugs.WarpError(err, fmt.Sprintf("ConsumeSerializer of %s error", rv.Type()))
+			}
+			num, endType, m := protowire.ConsumeTag(leftData)
+			if m < 0 || endType != protowire.EndGroupType || num != tag {
+				return buf, fmt.Errorf("[%s]%s end tag error,code=%d", debugs.SourceCodeLoc(1), rv.Type(), m)
+			}
+			return leftData[m:], nil
+		}
+	}
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if golangType, _, _, n := consumeHead(buf); n > 0 && golangType == tNull {
+			break
+		}
+		if rv.Type().Elem().Kind() == reflect.Struct {
+			if rv.IsNil() {
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			return decodeInto(buf, rv.Elem())
+		}
+	case reflect.Struct:
+		if isPlainType(rv.Type()) && getCustomType(rv.Type()) == nil {
+			return decodeStruct(buf, rv)
+		}
+	case reflect.Map:
+		golangType, _, typ, n := consumeHead(buf)
+		if n > 0 && typ == protowire.StartGroupType && (golangType == tMap || golangType == tAnyMap) {
+			return decodeMapInto(buf[n:], rv)
+		}
+	case reflect.Slice:
+		if isStructSliceType(rv.Type()) {
+			golangType, _, typ, n := consumeHead(buf)
+			if n > 0 && typ == protowire.StartGroupType && golangType == 0 {
+				return decodeSliceInto(buf[n:], rv)
+			}
+		}
+	}
+	leftData, value, err := decode(buf, 0)
+	if err != nil {
+		return buf, debugs.WarpError(err, "decode error")
+	}
+	if err = assignValue(rv, value); err != nil {
+		return buf, err
+	}
+	return leftData, nil
+}
+
+func decodeStruct(buf []byte, rv reflect.Value) ([]byte, error) {
+	info, err := getStructInfo(rv.Type())
+	if err != nil {
+		return buf, debugs.WarpError(err, "getStructInfo error")
+	}
+	_, typ, n := protowire.ConsumeTag(buf)
+	if n < 0 {
+		return buf, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	if typ != protowire.StartGroupType {
+		return buf, fmt.Errorf("[%s]not a struct, type=%d", debugs.SourceCodeLoc(1), typ)
+	}
+	buf = buf[n:]
+	body := buf
+	for len(buf) > 0 {
+		golangType, num, typ, headLen := consumeHead(buf)
+		if headLen < 0 {
+			return buf, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), headLen)
+		}
+		if typ == protowire.EndGroupType {
+			return buf[headLen:], nil
+		}
+		if golangType == tChecksum {
+			if n, err = consumeChecksum(body[:len(body)-len(buf)], buf); err != nil {
+				return buf, debugs.WarpError(err, "check struct checksum error")
+			}
+			buf = buf[n:]
+			continue
+		}
+		idx, ok := info.byNum[int(num)]
+		if !ok || golangType == tIndex {
+			if buf, err = skipValue(buf); err != nil {
+				return buf, debugs.WarpError(err, fmt.Sprintf("skip unknown field %d error", num))
+			}
+			continue
+		}
+		f := &info.fields[idx]
+		if buf, err = decodeInto(buf, rv.Field(f.index)); err != nil {
+			return buf, debugs.WarpError(err, fmt.Sprintf("decode field %s error", f.name))
+		}
+	}
+	return buf, nil
+}
+
+// decodeSliceInto decode the items after the array group start tag into rv, which is a slice of struct
+func decodeSliceInto(buf []byte, rv reflect.Value) ([]byte, error) {
+	out := reflect.MakeSlice(rv.Type(), 0, defaultArrayCount)
+	body := buf
+	for len(buf) > 0 {
+		golangType, _, typ, n := consumeHead(buf)
+		if n < 0 {
+			return buf, fmt.Errorf("[%s]decode array item end flag error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		if typ == protowire.EndGroupType {
+			buf = buf[n:]
+			break
+		}
+		var err error
+		switch golangType {
+		case tChecksum:
+			if n, err = consumeChecksum(body[:len(body)-len(buf)], buf); err != nil {
+				return buf, debugs.WarpError(err, "check array checksum error")
+			}
+			buf = buf[n:]
+			continue
+		case tIndex:
+			if buf, err = skipValue(buf); err != nil {
+				return buf, debugs.WarpError(err, "skip array index error")
+			}
+			continue
+		}
+		out = reflect.Append(out, reflect.Zero(rv.Type().Elem()))
+		if buf, err = decodeInto(buf, out.Index(out.Len()-1)); err != nil {
+			return buf, debugs.WarpError(err, fmt.Sprintf("decode item %d of %s error", out.Len()-1, rv.Type()))
+		}
+	}
+	rv.Set(out)
+	return buf, nil
+}
+
+// assignValue set the decoded value to rv, named types are converted
+func assignValue(rv reflect.Value, value interface{}) error {
+	if value == nil {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+	vv := reflect.ValueOf(value)
+	if vv.Type().AssignableTo(rv.Type()) {
+		rv.Set(vv)
+		return nil
+	}
+	if _, ok := basicTypes[vv.Kind()]; ok && vv.Kind() == rv.Kind() {
+		rv.Set(vv.Convert(rv.Type()))
+		return nil
+	}
+	if rv.Kind() == reflect.Ptr {
+		elem := reflect.New(rv.Type().Elem())
+		if err := assignValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		rv.Set(elem)
+		return nil
+	}
+	return fmt.Errorf("[%s]can not assign %T to %s", debugs.SourceCodeLoc(1), value, rv.Type())
+}
@@ -0,0 +1,137 @@
+package serializer
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testLevel int8
+
+type testInner struct {
+	Name  string `serializer:"3"`
+	Score float64
+}
+
+type testRow struct {
+	ID      int64                  `serializer:"1"`
+	Level   testLevel              `serializer:"3"`
+	Tags    []int32                `serializer:"4"`
+	Inner   testInner              `serializer:"5"`
+	Ptr     *testInner             `serializer:"6"`
+	Extra   map[string]interface{} `serializer:"7"`
+	Skip    string                 `serializer:"-"`
+	Count   *uint32                `serializer:"8"`
+	private int
+}
+
+func TestEncodeDecodeStruct(t *testing.T) {
+	count := uint32(9)
+	row := testRow{
+		ID:    -5,
+		Level: 3,
+		Tags:  []int32{1, -2},
+		Inner: testInner{Name: "in", Score: 1.5},
+		Ptr:   &testInner{Name: "ptr"},
+		Extra: map[string]interface{}{"a": "b"},
+		Skip:  "skip",
+		Count: &count,
+	}
+	buf, err := Encode(nil, 1, &row)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	var out testRow
+	leftData, err := DecodeInto(buf, &out)
+	if err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	if len(leftData) != 0 {
+		t.Errorf("left data len=%d", len(leftData))
+		return
+	}
+	row.Skip = ""
+	if !reflect.DeepEqual(row, out) {
+		t.Errorf("not equal, %+v", out)
+		return
+	}
+	// the generic Decode read struct as an array
+	_, values, err := Decode(buf)
+	if err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	arr, ok := values.([]interface{})
+	if !ok || len(arr) != 7 || arr[0] != int64(-5) || arr[1] != int8(3) {
+		t.Errorf("decode struct as array error, %+v", values)
+	}
+}
+
+func TestEncodeDecodeStructSlice(t *testing.T) {
+	type order struct {
+		Items  []testInner  `serializer:"1"`
+		Ptrs   []*testInner `serializer:"2"`
+		Nested [][]testInner
+		Empty  []testInner
+	}
+	o := order{
+		Items:  []testInner{{Name: "a", Score: 1}, {Name: "b"}},
+		Ptrs:   []*testInner{{Name: "p"}, nil},
+		Nested: [][]testInner{{{Name: "n"}}},
+		Empty:  []testInner{},
+	}
+	for _, version := range []int{FormatV1, FormatV2} {
+		buf, err := EncodeVersion(nil, 1, o, version)
+		if err != nil {
+			t.Errorf("encode error, err=%+v", err)
+			return
+		}
+		var out order
+		if _, err = DecodeInto(buf, &out); err != nil || !reflect.DeepEqual(o, out) {
+			t.Errorf("not equal, version=%d, out=%+v, err=%+v", version, out, err)
+		}
+	}
+	// the generic Decode read it as arrays
+	buf, _ := Encode(nil, 1, o.Items)
+	_, value, err := Decode(buf)
+	expect := []interface{}{[]interface{}{"a", float64(1)}, []interface{}{"b", float64(0)}}
+	if err != nil || !reflect.DeepEqual(expect, value) {
+		t.Errorf("decode error, value=%+v, err=%+v", value, err)
+	}
+}
+
+func TestDecodeIntoSkipUnknownField(t *testing.T) {
+	type v1 struct {
+		A int
+		B string
+		C []byte
+	}
+	type v2 struct {
+		A int
+		C []byte `serializer:"3"`
+	}
+	buf, err := Encode(nil, 1, v1{A: 1, B: "b", C: []byte("c")})
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	var out v2
+	if _, err = DecodeInto(buf, &out); err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual(v2{A: 1, C: []byte("c")}, out) {
+		t.Errorf("not equal, %+v", out)
+	}
+}
+
+func TestStructDuplicateFieldNumber(t *testing.T) {
+	type dup struct {
+		A int
+		B int `serializer:"1"`
+	}
+	if _, err := Encode(nil, 1, dup{}); err == nil {
+		t.Errorf("duplicate field number should fail")
+	}
+}
@@ -0,0 +1,57 @@
+package serializer
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// appendTime write time.Time as a bytes field, the content is:
+// unix seconds zigzag varint, nanoseconds varint, and zone offset seconds zigzag varint if the location is not UTC.
+// the monotonic clock is dropped, the location is kept as a fixed zone of the offset.
+func appendTime(buf []byte, tag int, t time.Time) []byte {
+	_, offset := t.Zone()
+	hasOffset := t.Location() != time.UTC
+	size := protowire.SizeVarint(protowire.EncodeZigZag(t.Unix())) + protowire.SizeVarint(uint64(t.Nanosecond()))
+	if hasOffset {
+		size += protowire.SizeVarint(protowire.EncodeZigZag(int64(offset)))
+	}
+	buf = setType(buf, tTime)
+	buf = protowire.AppendTag(buf, protowire.Number(tag), protowire.BytesType)
+	buf = protowire.AppendVarint(buf, uint64(size))
+	buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(t.Unix()))
+	buf = protowire.AppendVarint(buf, uint64(t.Nanosecond()))
+	if hasOffset {
+		buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(offset)))
+	}
+	return buf
+}
+
+// decodeTime decode the content written by appendTime
+func decodeTime(data []byte) (time.Time, error) {
+	sec, n := protowire.ConsumeVarint(data)
+	if n < 0 {
+		return time.Time{}, fmt.Errorf("[%s]read time seconds error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	data = data[n:]
+	nsec, n := protowire.ConsumeVarint(data)
+	if n < 0 || nsec >= uint64(time.Second) {
+		return time.Time{}, fmt.Errorf("[%s]read time nanoseconds error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	data = data[n:]
+	t := time.Unix(protowire.DecodeZigZag(sec), int64(nsec))
+	if len(data) == 0 {
+		return t.UTC(), nil
+	}
+	offset, n := protowire.ConsumeVarint(data)
+	if n != len(data) {
+		return time.Time{}, fmt.Errorf("[%s]read time zone offset error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	return t.In(time.FixedZone("", int(protowire.DecodeZigZag(offset)))), nil
+}
@@ -0,0 +1,60 @@
+package serializer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTime(t *testing.T) {
+	now := time.Now()
+	utc := time.Date(2021, 3, 4, 5, 6, 7, 123456789, time.UTC)
+	zone := time.Date(1960, 1, 2, 3, 4, 5, 6, time.FixedZone("", 8*3600))
+	d := -90 * time.Minute
+	buf, err := Encode(nil, 1, []interface{}{now, &utc, zone, d, &d})
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	if err = Validate(buf, ValidateOptions{}); err != nil {
+		t.Errorf("should be valid, err=%+v", err)
+	}
+	_, value, err := Decode(buf)
+	if err != nil {
+		t.Errorf("decode error, err=%+v", err)
+		return
+	}
+	items := value.([]interface{})
+	if got := items[0].(time.Time); !got.Equal(now) {
+		t.Errorf("not equal, %v, %v", got, now)
+	}
+	if got := items[1].(time.Time); !reflect.DeepEqual(utc, got) {
+		t.Errorf("not equal, %v, %v", got, utc)
+	}
+	if got := items[2].(time.Time); !got.Equal(zone) || got.Format(time.RFC3339Nano) != zone.Format(time.RFC3339Nano) {
+		t.Errorf("not equal, %v, %v", got, zone)
+	}
+	if items[3] != d || items[4] != d {
+		t.Errorf("duration not equal, %+v", items)
+	}
+	if s, _ := BasicTypeToString(utc); s != "2021-03-04T05:06:07.123456789Z" {
+		t.Errorf("format time error, %s", s)
+	}
+	if s, _ := BasicTypeToString(d); s != "-1h30m0s" {
+		t.Errorf("format duration error, %s", s)
+	}
+	type event struct {
+		At      time.Time
+		Elapsed time.Duration
+	}
+	e := event{At: utc, Elapsed: time.Second}
+	buf, _ = Encode(nil, 1, e)
+	_, value, _ = Decode(buf)
+	if !reflect.DeepEqual([]interface{}{utc, time.Second}, value) {
+		t.Errorf("struct fields not decoded as time, %+v", value)
+	}
+	var out event
+	if _, err = DecodeInto(buf, &out); err != nil || !reflect.DeepEqual(e, out) {
+		t.Errorf("DecodeInto not equal, out=%+v, err=%+v", out, err)
+	}
+}
@@ -23,5 +23,5 @@ func SourceCodeLoc(callDepth int) string {
 
 // WarpError warp line number info to error
 func WarpError(err error, infos ...string) error {
-	return fmt.Errorf("[%s] %+v, err=%+v", SourceCodeLoc(2), infos, err)
+	return fmt.Errorf("[%s] %+v, err=%w", SourceCodeLoc(2), infos, err)
 }
@@ -0,0 +1,378 @@
+package serializer
+
+import (
+	"fmt"
+	"hash/crc32"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+const defaultValidateMaxDepth = 100
+
+// ValidateOptions config of Validate, zero value means default
+type ValidateOptions struct {
+	MaxDepth int // max nesting level of arrays and maps, default is 100
+
+	AllowUnknownTypes bool // values of unknown data types are only checked for the wire format, see RawValue
+}
+
+// Validate check the whole buffer without decoding and allocating, for the buffers from untrusted input.
+// it checks: tags and wire types are well formed, arrays and maps are closed by the end tag of same number,
+// data types match their wire types, bool values are 0 or 1, the CRC32C trailers of V1 arrays.
+// the content of json and compression frame is not checked.
+func Validate(buf []byte, opts ValidateOptions) error {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = defaultValidateMaxDepth
+	}
+	version, body, err := ParseHeader(buf)
+	if err != nil {
+		return debugs.WarpError(err, "ParseHeader error")
+	}
+	v := validator{version: version, maxDepth: opts.MaxDepth, allowUnknownTypes: opts.AllowUnknownTypes}
+	for offset := 0; offset < len(body); {
+		n, err := v.value(body[offset:], 0, 0)
+		if err != nil {
+			return debugs.WarpError(err, fmt.Sprintf("invalid value at offset %d", len(buf)-len(body)+offset))
+		}
+		offset += n
+	}
+	return nil
+}
+
+type validator struct {
+	version           int
+	maxDepth          int
+	allowUnknownTypes bool
+}
+
+// value check one value, itemType is used if the value has no data type field and is not a group,
+// return the length of value
+func (v *validator) value(buf []byte, itemType uint64, depth int) (int, error) {
+	golangType, tag, typ, n := readHead(buf, v.version)
+	if n < 0 {
+		return 0, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	if golangType == 0 && typ != protowire.StartGroupType {
+		golangType = itemType // a group in a column always has its own data type, or is an array
+	}
+	if golangType >= tBuiltinEnd && typ != protowire.EndGroupType &&
+		(v.allowUnknownTypes || isRegisteredType(golangType, typ)) {
+		valueLen := consumeValue(buf, v.version)
+		if valueLen < 0 {
+			return 0, fmt.Errorf("[%s]read unknown data type %d error,code=%d", debugs.SourceCodeLoc(1), golangType, valueLen)
+		}
+		return valueLen, nil
+	}
+	switch typ {
+	case protowire.EndGroupType:
+		return 0, fmt.Errorf("[%s]unexpected end tag %d", debugs.SourceCodeLoc(1), tag)
+	case protowire.StartGroupType:
+		if depth >= v.maxDepth {
+			return 0, fmt.Errorf("[%s]nesting is deeper than %d", debugs.SourceCodeLoc(1), v.maxDepth)
+		}
+		var (
+			bodyLen int
+			err     error
+		)
+		switch golangType {
+		case 0:
+			bodyLen, _, err = v.items(buf[n:], tag, 0, depth+1)
+		case tMap, tAnyMap:
+			bodyLen, err = v.mapItems(buf[n:], tag, golangType, depth+1)
+		case tColumns:
+			bodyLen, err = v.columns(buf[n:], tag, depth+1)
+		case tStringSlice, tBytesSlice:
+			bodyLen, err = v.repeated(buf[n:], tag)
+		default:
+			return 0, fmt.Errorf("[%s]data type %d not a group", debugs.SourceCodeLoc(1), golangType)
+		}
+		return n + bodyLen, err
+	}
+	expect, ok := wireTypeOf(golangType)
+	if !ok {
+		return 0, fmt.Errorf("[%s]unknown data type %d", debugs.SourceCodeLoc(1), golangType)
+	}
+	if typ != expect {
+		return 0, fmt.Errorf("[%s]data type %d with wire type %d, expect %d", debugs.SourceCodeLoc(1), golangType, typ, expect)
+	}
+	data := buf[n:]
+	switch typ {
+	case protowire.VarintType:
+		value, valueLen := protowire.ConsumeVarint(data)
+		if valueLen < 0 {
+			return 0, fmt.Errorf("[%s]read VarintType error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+		}
+		if golangType == tBool && value > 1 {
+			return 0, fmt.Errorf("[%s]not a bool value, %d", debugs.SourceCodeLoc(1), value)
+		}
+		return n + valueLen, nil
+	case protowire.Fixed32Type:
+		_, valueLen := protowire.ConsumeFixed32(data)
+		if valueLen < 0 {
+			return 0, fmt.Errorf("[%s]read Fixed32Type error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+		}
+		return n + valueLen, nil
+	case protowire.Fixed64Type:
+		_, valueLen := protowire.ConsumeFixed64(data)
+		if valueLen < 0 {
+			return 0, fmt.Errorf("[%s]read Fixed64Type error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+		}
+		return n + valueLen, nil
+	default:
+		value, valueLen := protowire.ConsumeBytes(data)
+		if valueLen < 0 {
+			return 0, fmt.Errorf("[%s]read BytesType error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+		}
+		if err := validateBytes(value, golangType); err != nil {
+			return 0, err
+		}
+		return n + valueLen, nil
+	}
+}
+
+// items check the array items after the group start tag, return the length to the end tag(included)
+// and the count of values, the trailers are not counted
+func (v *validator) items(buf []byte, tag protowire.Number, itemType uint64, depth int) (int, uint64, error) {
+	count := uint64(0)
+	for offset := 0; ; {
+		golangType, num, typ, n := readHead(buf[offset:], v.version)
+		if n < 0 {
+			return 0, 0, fmt.Errorf("[%s]read array item error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		if typ == protowire.EndGroupType {
+			if num != tag {
+				return 0, 0, fmt.Errorf("[%s]array end tag %d not match %d", debugs.SourceCodeLoc(1), num, tag)
+			}
+			return offset + n, count, nil
+		}
+		var (
+			itemLen int
+			err     error
+		)
+		switch golangType {
+		case tChecksum:
+			itemLen, err = v.checksum(buf[:offset], buf[offset:])
+		case tIndex:
+			itemLen, err = v.trailer(buf[offset:], typ, n)
+		default:
+			itemLen, err = v.value(buf[offset:], itemType, depth)
+			count++
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		offset += itemLen
+	}
+}
+
+// mapItems check the key/value pairs after the map group start tag, keys of tMap must be strings
+func (v *validator) mapItems(buf []byte, tag protowire.Number, mapType uint64, depth int) (int, error) {
+	for offset := 0; ; {
+		golangType, num, typ, n := readHead(buf[offset:], v.version)
+		if n < 0 {
+			return 0, fmt.Errorf("[%s]read map key error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		if typ == protowire.EndGroupType {
+			if num != tag {
+				return 0, fmt.Errorf("[%s]map end tag %d not match %d", debugs.SourceCodeLoc(1), num, tag)
+			}
+			return offset + n, nil
+		}
+		if mapType == tMap && golangType != tString {
+			return 0, fmt.Errorf("[%s]map key not a string, data type=%d", debugs.SourceCodeLoc(1), golangType)
+		}
+		keyLen, err := v.value(buf[offset:], 0, depth)
+		if err != nil {
+			return 0, debugs.WarpError(err, "invalid map key")
+		}
+		offset += keyLen
+		if _, _, typ, n = readHead(buf[offset:], v.version); n > 0 && typ == protowire.EndGroupType {
+			return 0, fmt.Errorf("[%s]map key without value", debugs.SourceCodeLoc(1))
+		}
+		valueLen, err := v.value(buf[offset:], 0, depth)
+		if err != nil {
+			return 0, debugs.WarpError(err, "invalid map value")
+		}
+		offset += valueLen
+	}
+}
+
+// columns check the column-major rows after the group start tag, see EncodeColumns
+func (v *validator) columns(buf []byte, tag protowire.Number, depth int) (int, error) {
+	golangType, num, typ, n := readHead(buf, v.version)
+	if n < 0 || golangType != 0 || num != tagOfRowCount || typ != protowire.VarintType {
+		return 0, fmt.Errorf("[%s]read row count error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	count, countLen := protowire.ConsumeVarint(buf[n:])
+	if countLen < 0 {
+		return 0, fmt.Errorf("[%s]read row count error,code=%d", debugs.SourceCodeLoc(1), countLen)
+	}
+	hasColumn := false
+	for offset := n + countLen; ; {
+		colType, num, typ, n := readHead(buf[offset:], v.version)
+		if n < 0 {
+			return 0, fmt.Errorf("[%s]read column error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		if typ == protowire.EndGroupType {
+			if num != tag {
+				return 0, fmt.Errorf("[%s]columns end tag %d not match %d", debugs.SourceCodeLoc(1), num, tag)
+			}
+			if !hasColumn && count != 0 {
+				return 0, fmt.Errorf("[%s]no columns for %d rows", debugs.SourceCodeLoc(1), count)
+			}
+			return offset + n, nil
+		}
+		if typ != protowire.StartGroupType {
+			return 0, fmt.Errorf("[%s]column not a group, type=%d", debugs.SourceCodeLoc(1), typ)
+		}
+		if depth >= v.maxDepth {
+			return 0, fmt.Errorf("[%s]nesting is deeper than %d", debugs.SourceCodeLoc(1), v.maxDepth)
+		}
+		colLen, values, err := v.items(buf[offset+n:], num, colType, depth+1)
+		if err != nil {
+			return 0, debugs.WarpError(err, fmt.Sprintf("invalid column %d", num))
+		}
+		if values != count {
+			return 0, fmt.Errorf("[%s]column %d has %d values, expect %d", debugs.SourceCodeLoc(1), num, values, count)
+		}
+		hasColumn = true
+		offset += n + colLen
+	}
+}
+
+// repeated check the items of []string or [][]byte after the group start tag
+func (v *validator) repeated(buf []byte, tag protowire.Number) (int, error) {
+	for offset := 0; ; {
+		golangType, num, typ, n := readHead(buf[offset:], v.version)
+		if n < 0 {
+			return 0, fmt.Errorf("[%s]read repeated item error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		if typ == protowire.EndGroupType {
+			if num != tag {
+				return 0, fmt.Errorf("[%s]repeated end tag %d not match %d", debugs.SourceCodeLoc(1), num, tag)
+			}
+			return offset + n, nil
+		}
+		if golangType != 0 || typ != protowire.BytesType {
+			return 0, fmt.Errorf("[%s]repeated item not a BytesType, type=%d, data type=%d", debugs.SourceCodeLoc(1), typ, golangType)
+		}
+		_, valueLen := protowire.ConsumeBytes(buf[offset+n:])
+		if valueLen < 0 {
+			return 0, fmt.Errorf("[%s]read BytesType error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+		}
+		offset += n + valueLen
+	}
+}
+
+// checksum check the CRC32C trailer of array, body is the items before it
+func (v *validator) checksum(body []byte, item []byte) (int, error) {
+	sum, n, err := readChecksum(item, v.version)
+	if err != nil {
+		return 0, err
+	}
+	// the checksum is computed over V1 body, V2 can not be checked without converting
+	if v.version == FormatV1 && crc32.Checksum(body, crc32cTable) != sum {
+		return 0, debugs.WarpError(ErrChecksumMismatch, fmt.Sprintf("expect %08x", sum))
+	}
+	return n, nil
+}
+
+// trailer check the index trailer of array
+func (v *validator) trailer(item []byte, typ protowire.Type, n int) (int, error) {
+	if typ != protowire.BytesType {
+		return 0, fmt.Errorf("[%s]index trailer not a BytesType, type=%d", debugs.SourceCodeLoc(1), typ)
+	}
+	valueLen := protowire.ConsumeFieldValue(1, typ, item[n:])
+	if valueLen < 0 {
+		return 0, fmt.Errorf("[%s]read index trailer error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+	}
+	return n + valueLen, nil
+}
+
+// isRegisteredType check a value of the type registered by RegisterType
+func isRegisteredType(golangType uint64, typ protowire.Type) bool {
+	if golangType < MinUserTypeID {
+		return false
+	}
+	t := getCustomTypeByID(golangType)
+	if t == nil {
+		return false
+	}
+	if t.enc == nil {
+		return typ == protowire.StartGroupType
+	}
+	return typ == protowire.BytesType
+}
+
+// wireTypeOf return the wire type of a data type which is not a group
+func wireTypeOf(golangType uint64) (protowire.Type, bool) {
+	switch golangType {
+	case tBool, tInt8, tUint8, tInt16, tUint16, tInt32, tUint32, tInt64, tUint64, tInt,
+		tSint8, tSint16, tSint32, tSint64, tSint, tDuration, tNull:
+		return protowire.VarintType, true
+	case tFloat32:
+		return protowire.Fixed32Type, true
+	case tFloat64:
+		return protowire.Fixed64Type, true
+	case tString, tBytes, tJSON, tCompressed, tTime,
+		tInt8Slice, tInt16Slice, tUint16Slice, tInt32Slice, tUint32Slice, tInt64Slice, tUint64Slice, tIntSlice,
+		tFloat32Slice, tFloat64Slice, tBoolSlice:
+		return protowire.BytesType, true
+	default:
+		return 0, false
+	}
+}
+
+// validateBytes check the content of a BytesType value
+func validateBytes(data []byte, golangType uint64) error {
+	switch golangType {
+	case tFloat32Slice:
+		if len(data)%4 != 0 {
+			return fmt.Errorf("[%s]packed float32 length error, len=%d", debugs.SourceCodeLoc(1), len(data))
+		}
+	case tFloat64Slice:
+		if len(data)%8 != 0 {
+			return fmt.Errorf("[%s]packed float64 length error, len=%d", debugs.SourceCodeLoc(1), len(data))
+		}
+	case tInt8Slice, tInt16Slice, tUint16Slice, tInt32Slice, tUint32Slice, tInt64Slice, tUint64Slice, tIntSlice:
+		for len(data) > 0 {
+			_, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return fmt.Errorf("[%s]read packed varint error,code=%d", debugs.SourceCodeLoc(1), n)
+			}
+			data = data[n:]
+		}
+	case tBoolSlice:
+		for _, c := range data {
+			if c > 1 {
+				return fmt.Errorf("[%s]not a bool value, %d", debugs.SourceCodeLoc(1), c)
+			}
+		}
+	case tTime:
+		// seconds, nanoseconds and the optional zone offset
+		for i := 0; len(data) > 0; i++ {
+			_, n := protowire.ConsumeVarint(data)
+			if n < 0 || i >= 3 {
+				return fmt.Errorf("[%s]time content error,code=%d", debugs.SourceCodeLoc(1), n)
+			}
+			data = data[n:]
+		}
+	case tCompressed:
+		codec, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return fmt.Errorf("[%s]read codec error,code=%d", debugs.SourceCodeLoc(1), n)
+		}
+		if _, m := protowire.ConsumeVarint(data[n:]); m < 0 {
+			return fmt.Errorf("[%s]read raw length error,code=%d", debugs.SourceCodeLoc(1), m)
+		}
+		compressorsLock.RLock()
+		_, ok := compressors[codec]
+		compressorsLock.RUnlock()
+		if !ok {
+			return fmt.Errorf("[%s]compressor %d not registered", debugs.SourceCodeLoc(1), codec)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package serializer
+
+import (
+	"compress/flate"
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func getValidBuffers() [][]byte {
+	data := getTestData()
+	rows := getTestRows()
+	v1, _ := Encode(nil, 1, data)
+	v2, _ := EncodeVersion(nil, 1, data, FormatV2)
+	packed, _ := Encode(nil, 1, []interface{}{[]int8{-1, 2}, []uint64{1 << 60}, []float32{1.5}, []float64{2.5}})
+	columns, _ := EncodeColumns(nil, 1, rows)
+	columnsV2, _, _ := convertValue(AppendHeader(nil, FormatV2), columns, FormatV1, FormatV2)
+	// arrays and structs in a scalar column have no data type field
+	mixedColumns, _ := EncodeColumns(nil, 1, [][]interface{}{{int64(1)}, {[]interface{}{1}}, {testInner{Name: "x"}}})
+	withChecksum, _ := EncodeWithChecksum(nil, 1, rows)
+	withIndex, _ := AppendIndexTrailer(append([]byte{}, withChecksum...))
+	compressed, _ := CompressArray(nil, v1, flate.BestSpeed)
+	twoValues, _ := Encode(append([]byte{}, v1...), 2, "abc")
+	return [][]byte{v1, v2, packed, columns, columnsV2, withChecksum, withIndex, compressed, twoValues, mixedColumns, nil}
+}
+
+func TestValidate(t *testing.T) {
+	for idx, buf := range getValidBuffers() {
+		if err := Validate(buf, ValidateOptions{}); err != nil {
+			t.Errorf("case %d should be valid, err=%+v", idx, err)
+		}
+	}
+	v1, _ := Encode(nil, 1, getTestData())
+	badBool := protowire.AppendVarint(protowire.AppendTag(setType(nil, tBool), 1, protowire.VarintType), 2)
+	badWireType := protowire.AppendVarint(protowire.AppendTag(setType(nil, tString), 1, protowire.VarintType), 2)
+	unknownType := protowire.AppendVarint(protowire.AppendTag(setType(nil, 1000), 1, protowire.VarintType), 2)
+	notMatchEnd := AppendArrayEnd(AppendArrayStart(nil, 1), 2)
+	unexpectedEnd := AppendArrayEnd(nil, 1)
+	badPacked := appendPackedHead(nil, 1, tFloat64Slice, 3)
+	badPacked = append(badPacked, 1, 2, 3)
+	deep := AppendArrayStart(nil, 1)
+	for i := 0; i < 5; i++ {
+		deep = AppendArrayStart(deep, 1)
+	}
+	for i := 0; i < 6; i++ {
+		deep = AppendArrayEnd(deep, 1)
+	}
+	withChecksum, _ := EncodeWithChecksum(nil, 1, getTestRows())
+	withChecksum[3] ^= 0x01
+	// the row count of columns is not confirmed by the data
+	hugeCount := AppendArrayEnd(protowire.AppendVarint(protowire.AppendTag(
+		protowire.AppendTag(setType(nil, tColumns), 1, protowire.StartGroupType), tagOfRowCount, protowire.VarintType), 1<<40), 1)
+	lessValues, _ := EncodeColumns(nil, 1, [][]interface{}{{1}, {2}})
+	lessValues[4] = 3
+	cases := [][]byte{
+		v1[:len(v1)-1], badBool, badWireType, unknownType, notMatchEnd, unexpectedEnd, badPacked, withChecksum,
+		hugeCount, lessValues,
+	}
+	for idx, buf := range cases {
+		if err := Validate(buf, ValidateOptions{}); err == nil {
+			t.Errorf("case %d should be invalid", idx)
+		}
+	}
+	if err := Validate(deep, ValidateOptions{MaxDepth: 5}); err == nil {
+		t.Errorf("deep nesting should be invalid")
+	}
+	if err := Validate(deep, ValidateOptions{MaxDepth: 6}); err != nil {
+		t.Errorf("should be valid, err=%+v", err)
+	}
+	if err := Validate(withChecksum, ValidateOptions{}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("should be ErrChecksumMismatch, err=%+v", err)
+	}
+}
+
+func TestValidateNoAlloc(t *testing.T) {
+	for idx, buf := range getValidBuffers() {
+		allocs := testing.AllocsPerRun(10, func() {
+			_ = Validate(buf, ValidateOptions{})
+		})
+		if allocs != 0 {
+			t.Errorf("case %d Validate allocs %f times", idx, allocs)
+		}
+	}
+}
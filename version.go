@@ -0,0 +1,200 @@
+package serializer
+
+import (
+	"bytes"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// format versions
+const (
+	// FormatV1 is the default format: a data type field(tag 15) before the data field.
+	// a buffer without header is always V1.
+	FormatV1 = 1
+	// FormatV2 fold the data type into the low bits of the field number: tag<<6 | dataType,
+	// it reduces 1 byte for each value. a data type >= 64 still use the data type field.
+	FormatV2 = 2
+)
+
+const (
+	v2TypeBits = 6
+	v2TypeMask = 1<<v2TypeBits - 1
+	v2MaxTag   = protowire.MaxValidNumber >> v2TypeBits
+)
+
+// magic of the header, field number 0 is invalid in protocol buffers, so a V1 buffer never starts with it
+var headerMagic = []byte{0x00, 'S', 'Z'}
+
+// AppendHeader add the format header(magic + version varint) to buffer, the header is optional for V1
+func AppendHeader(buf []byte, version int) []byte {
+	buf = append(buf, headerMagic...)
+	return protowire.AppendVarint(buf, uint64(version))
+}
+
+// ParseHeader read the format header, a buffer without header is V1, body is the data after header
+func ParseHeader(buf []byte) (version int, body []byte, err error) {
+	if !bytes.HasPrefix(buf, headerMagic) {
+		return FormatV1, buf, nil
+	}
+	v, n := protowire.ConsumeVarint(buf[len(headerMagic):])
+	if n < 0 {
+		return 0, buf, fmt.Errorf("[%s]read format version error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	switch v {
+	case FormatV1, FormatV2:
+	default:
+		return 0, buf, fmt.Errorf("[%s]not support format version %d", debugs.SourceCodeLoc(1), v)
+	}
+	return int(v), buf[len(headerMagic)+n:], nil
+}
+
+// EncodeVersion add the format header, then encode v in the format version
+func EncodeVersion(buf []byte, tag int, v interface{}, version int) ([]byte, error) {
+	switch version {
+	case FormatV1:
+		return Encode(AppendHeader(buf, version), tag, v)
+	case FormatV2:
+		temp, err := Encode(nil, tag, v)
+		if err != nil {
+			return buf, err
+		}
+		out, _, err := convertValue(AppendHeader(buf, version), temp, FormatV1, FormatV2)
+		if err != nil {
+			return buf, debugs.WarpError(err, "convert to V2 error")
+		}
+		return out, nil
+	default:
+		return buf, fmt.Errorf("[%s]not support format version %d", debugs.SourceCodeLoc(1), version)
+	}
+}
+
+// toV1 remove the header of buf, and convert all the values to V1
+func toV1(buf []byte) ([]byte, error) {
+	version, body, err := ParseHeader(buf)
+	if err != nil {
+		return buf, err
+	}
+	if version == FormatV1 {
+		return body, nil
+	}
+	out := make([]byte, 0, len(body)+len(body)/2)
+	for len(body) > 0 {
+		var n int
+		if out, n, err = convertValue(out, body, version, FormatV1); err != nil {
+			return buf, debugs.WarpError(err, "convert to V1 error")
+		}
+		body = body[n:]
+	}
+	return out, nil
+}
+
+// readHead read the data type and tag of a value in the format version
+func readHead(buf []byte, version int) (golangType uint64, tag protowire.Number, typ protowire.Type, n int) {
+	if version == FormatV1 {
+		return consumeHead(buf)
+	}
+	golangType, tag, typ, n = consumeHead(buf)
+	if n < 0 {
+		return
+	}
+	if golangType == 0 {
+		golangType = uint64(tag & v2TypeMask)
+	}
+	tag >>= v2TypeBits
+	return
+}
+
+// appendHead write the data type and tag of a value in the format version, golangType 0 means no data type
+func appendHead(buf []byte, golangType uint64, tag protowire.Number, typ protowire.Type, version int) ([]byte, error) {
+	if version == FormatV1 {
+		if golangType != 0 && typ != protowire.EndGroupType {
+			buf = setType(buf, golangType)
+		}
+		return protowire.AppendTag(buf, tag, typ), nil
+	}
+	if tag > v2MaxTag {
+		return buf, fmt.Errorf("[%s]tag %d is too large for V2", debugs.SourceCodeLoc(1), tag)
+	}
+	if golangType > v2TypeMask {
+		if typ != protowire.EndGroupType {
+			buf = setType(buf, golangType)
+		}
+		return protowire.AppendTag(buf, tag<<v2TypeBits, typ), nil
+	}
+	return protowire.AppendTag(buf, tag<<v2TypeBits|protowire.Number(golangType), typ), nil
+}
+
+// convertValue convert one value at buf from a format version to another, return the length of value in buf
+func convertValue(dst []byte, buf []byte, from int, to int) ([]byte, int, error) {
+	golangType, tag, typ, n := readHead(buf, from)
+	if n < 0 {
+		return dst, 0, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	dst, err := appendHead(dst, golangType, tag, typ, to)
+	if err != nil {
+		return dst, 0, err
+	}
+	if typ != protowire.StartGroupType {
+		valueLen := protowire.ConsumeFieldValue(tag, typ, buf[n:])
+		if valueLen < 0 {
+			return dst, 0, fmt.Errorf("[%s]read field value error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+		}
+		return append(dst, buf[n:n+valueLen]...), n + valueLen, nil
+	}
+	offset := n
+	for {
+		_, endTag, endType, endLen := readHead(buf[offset:], from)
+		if endLen < 0 {
+			return dst, 0, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), endLen)
+		}
+		if endType == protowire.EndGroupType {
+			if endTag != tag {
+				return dst, 0, fmt.Errorf("[%s]group end tag %d not match %d", debugs.SourceCodeLoc(1), endTag, tag)
+			}
+			dst, err = appendHead(dst, golangType, tag, protowire.EndGroupType, to)
+			return dst, offset + endLen, err
+		}
+		var itemLen int
+		if dst, itemLen, err = convertValue(dst, buf[offset:], from, to); err != nil {
+			return dst, 0, err
+		}
+		offset += itemLen
+	}
+}
+
+// consumeValue return the length of one value at buf in the format version, or a protowire error code
+func consumeValue(buf []byte, version int) int {
+	_, tag, typ, n := readHead(buf, version)
+	if n < 0 {
+		return n
+	}
+	if version == FormatV1 || typ != protowire.StartGroupType {
+		_, rawTag, _, _ := consumeHead(buf)
+		valueLen := protowire.ConsumeFieldValue(rawTag, typ, buf[n:])
+		if valueLen < 0 {
+			return valueLen
+		}
+		return n + valueLen
+	}
+	// the end tag of a V2 group has no data type bits, protowire can not match it
+	for offset := n; ; {
+		_, endTag, endType, endLen := readHead(buf[offset:], version)
+		if endLen < 0 {
+			return endLen
+		}
+		if endType == protowire.EndGroupType {
+			if endTag != tag {
+				return protowire.ConsumeFieldValue(tag, protowire.EndGroupType, nil) // the code of mismatching end group
+			}
+			return offset + endLen
+		}
+		itemLen := consumeValue(buf[offset:], version)
+		if itemLen < 0 {
+			return itemLen
+		}
+		offset += itemLen
+	}
+}
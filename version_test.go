@@ -0,0 +1,103 @@
+package serializer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncodeVersion(t *testing.T) {
+	arr := getTestData()
+	arr = append(arr, []int64{1, -1}, map[string]interface{}{"k": []interface{}{}})
+	v1, err := Encode(nil, 1, arr)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	for _, version := range []int{FormatV1, FormatV2} {
+		buf, err := EncodeVersion(nil, 1, arr, version)
+		if err != nil {
+			t.Errorf("encode V%d error, err=%+v", version, err)
+			return
+		}
+		if version == FormatV2 && len(buf) >= len(v1) {
+			t.Errorf("V2 should be smaller, %d >= %d", len(buf), len(v1))
+		}
+		leftData, values, err := Decode(buf)
+		if err != nil {
+			t.Errorf("decode V%d error, err=%+v", version, err)
+			return
+		}
+		if len(leftData) != 0 {
+			t.Errorf("left data len=%d", len(leftData))
+		}
+		if !reflect.DeepEqual(arr, values) {
+			t.Errorf("V%d not equal, %+v", version, values)
+		}
+		// convert back to V1
+		temp, err := toV1(buf)
+		if err != nil {
+			t.Errorf("convert V%d error, err=%+v", version, err)
+			return
+		}
+		if !reflect.DeepEqual(v1, temp) {
+			t.Errorf("convert V%d to V1 not equal", version)
+		}
+	}
+}
+
+func TestReadEachRowV2(t *testing.T) {
+	rows := getTestRows()
+	var got [][]interface{}
+	callback := func(tag int, cols ...interface{}) error {
+		got = append(got, cols)
+		return nil
+	}
+	buf, err := EncodeVersion(nil, 1, rows, FormatV2)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	if err = ReadEachRow(buf, callback); err != nil {
+		t.Errorf("read error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual(rows, got) {
+		t.Errorf("not equal, %+v", got)
+		return
+	}
+	// columns in V2
+	buf, err = EncodeColumns(nil, 1, rows)
+	if err != nil {
+		t.Errorf("encode error, err=%+v", err)
+		return
+	}
+	buf, _, err = convertValue(AppendHeader(nil, FormatV2), buf, FormatV1, FormatV2)
+	if err != nil {
+		t.Errorf("convert error, err=%+v", err)
+		return
+	}
+	got = got[:0]
+	if err = ReadColumns(buf, callback); err != nil {
+		t.Errorf("read error, err=%+v", err)
+		return
+	}
+	if !reflect.DeepEqual(rows, got) {
+		t.Errorf("not equal, %+v", got)
+	}
+}
+
+func TestV2LargeType(t *testing.T) {
+	buf := AppendHeader(nil, FormatV2)
+	buf, err := appendHead(buf, 100, 3, 0, FormatV2)
+	if err != nil {
+		t.Errorf("append head error, err=%+v", err)
+		return
+	}
+	golangType, tag, _, n := readHead(buf[len(headerMagic)+1:], FormatV2)
+	if n < 0 || golangType != 100 || tag != 3 {
+		t.Errorf("read head error, type=%d, tag=%d", golangType, tag)
+	}
+	if _, _, err = ParseHeader(AppendHeader(nil, 99)); err == nil {
+		t.Errorf("unknown version should fail")
+	}
+}
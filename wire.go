@@ -0,0 +1,47 @@
+package serializer
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ahfuzhang/serializer/util/debugs"
+)
+
+// consumeHead read the optional data type field and the tag of next value,
+// n is the length of them, a negative n is the protowire error code
+func consumeHead(buf []byte) (golangType uint64, num protowire.Number, typ protowire.Type, n int) {
+	num, typ, n = protowire.ConsumeTag(buf)
+	if n < 0 {
+		return
+	}
+	if num != tagOfDataType || typ != protowire.VarintType {
+		return
+	}
+	var typeLen, tagLen int
+	golangType, typeLen = protowire.ConsumeVarint(buf[n:])
+	if typeLen < 0 {
+		n = typeLen
+		return
+	}
+	num, typ, tagLen = protowire.ConsumeTag(buf[n+typeLen:])
+	if tagLen < 0 {
+		n = tagLen
+		return
+	}
+	n += typeLen + tagLen
+	return
+}
+
+// skipValue skip next value(with its data type field), return the data after it
+func skipValue(buf []byte) ([]byte, error) {
+	_, num, typ, n := consumeHead(buf)
+	if n < 0 {
+		return buf, fmt.Errorf("[%s]read field error,code=%d", debugs.SourceCodeLoc(1), n)
+	}
+	valueLen := protowire.ConsumeFieldValue(num, typ, buf[n:])
+	if valueLen < 0 {
+		return buf, fmt.Errorf("[%s]read field value error,code=%d", debugs.SourceCodeLoc(1), valueLen)
+	}
+	return buf[n+valueLen:], nil
+}